@@ -1,7 +1,10 @@
 package main
 
 import (
+	"flag"
+
 	"github.com/pmouraguedes/battleship/internal/client"
+	"github.com/pmouraguedes/battleship/internal/game"
 )
 
 func main() {
@@ -13,7 +16,23 @@ func main() {
 	// 	return
 	// }
 
-	c, err := client.NewClient()
+	replay := flag.String("replay", "", "match log to replay instead of playing")
+	rulesets := flag.String("rulesets", "", "JSON file with extra rulesets and ship shapes")
+	flag.Parse()
+
+	if *rulesets != "" {
+		if err := game.LoadRulesetsFile(*rulesets); err != nil {
+			panic(err)
+		}
+	}
+
+	var c *client.Client
+	var err error
+	if *replay != "" {
+		c, err = client.NewReplayClient(*replay)
+	} else {
+		c, err = client.NewClient()
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -1,8 +1,42 @@
 package main
 
-import "github.com/pmouraguedes/battleship/internal/server"
+import (
+	"flag"
+	"log"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
 
 func main() {
-	s := server.NewServer(":8000")
+	rulesets := flag.String("rulesets", "", "JSON file with extra rulesets and ship shapes")
+	matchLogs := flag.String("matchlogs", "", "directory to write a replayable log of every game to")
+	attackTimeout := flag.Duration("attack-timeout", 0, "time allowed for each attack, 0 for no limit")
+	bank := flag.Duration("bank", 0, "total thinking time of each player, 0 for no limit")
+	setupTimeout := flag.Duration("setup-timeout", 0, "time allowed to place the fleet and send READY, 0 for no limit")
+	onTimeout := flag.String("on-timeout", "lose", "what a missed attack deadline costs: lose or random-shot")
+	omniscient := flag.Bool("omniscient", false, "show spectators where the ships are, for commentators")
+	flag.Parse()
+
+	if *rulesets != "" {
+		if err := game.LoadRulesetsFile(*rulesets); err != nil {
+			log.Fatal(err)
+		}
+	}
+	timeoutPolicy, err := game.ParseTimeoutPolicy(*onTimeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	config := server.DefaultConfig()
+	config.MatchLogDir = *matchLogs
+	config.Omniscient = *omniscient
+	config.TimeControl = game.TimeControl{
+		AttackTimeout: *attackTimeout,
+		Bank:          *bank,
+		SetupTimeout:  *setupTimeout,
+		OnTimeout:     timeoutPolicy,
+	}
+	s := server.NewServerWithConfig(":8000", config)
 	s.Start()
 }
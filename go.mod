@@ -2,11 +2,13 @@ module github.com/pmouraguedes/battleship
 
 go 1.23.2
 
-require github.com/rivo/tview v0.0.0-20250501113434-0c592cd31026
+require (
+	github.com/gdamore/tcell/v2 v2.7.1
+	github.com/rivo/tview v0.0.0-20250501113434-0c592cd31026
+)
 
 require (
 	github.com/gdamore/encoding v1.0.0 // indirect
-	github.com/gdamore/tcell/v2 v2.7.1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-runewidth v0.0.15 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
@@ -0,0 +1,273 @@
+package ai
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net"
+	"strings"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/protocol"
+)
+
+// maxRejections is how many shots in a row the server may turn down before
+// the bot resigns, rather than wait for a turn that never comes.
+const maxRejections = 3
+
+// Bot plays one game over a protocol connection, exactly like a remote
+// client would.
+type Bot struct {
+	name     string
+	level    Level
+	presets  game.Presets // the rulesets WELCOME may name
+	rng      *rand.Rand
+	strategy strategy      // set up once WELCOME tells us the rules
+	rules    *game.Ruleset // known after WELCOME
+	code     string        // our player code, known after WELCOME
+}
+
+func NewBot(name string, level Level, presets game.Presets) *Bot {
+	return &Bot{
+		name:    name,
+		level:   level,
+		presets: presets,
+		rng:     rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+// Play says HELLO, places a random fleet and then plays until the server
+// hangs up.
+func (b *Bot) Play(conn net.Conn) error {
+	c := protocol.NewConn(conn)
+	defer c.Close()
+
+	// Read on a separate goroutine, so the server never blocks writing to us
+	// while we are writing to it.
+	lines := make(chan string, 64)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := c.ReadLine()
+			if err != nil {
+				return
+			}
+			lines <- line
+		}
+	}()
+
+	if err := b.setup(c, lines); err != nil {
+		return err
+	}
+
+	firing := false // our turn, shots go one at a time
+	fired := 0      // shots of this turn
+	var pending *game.Vector2
+	var salvo []game.Vector2 // our salvo, until the server takes it
+	ourSalvo := false        // reading the outcome of our own salvo
+	rejected := 0            // shots turned down in a row
+	for line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "TURN":
+			if fields[1] != b.code {
+				continue
+			}
+			if b.rules.Turns.Salvo() {
+				var err error
+				salvo, err = b.fireSalvo(c, turnShots(fields), b.strategy.next)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			firing, fired = true, 0
+		case "SALVO":
+			ourSalvo = fields[1] == b.code
+			if ourSalvo {
+				salvo, rejected = nil, 0
+			}
+			continue
+		case "END":
+			ourSalvo = false
+			continue
+		case "HIT", "MISS", "SUNK":
+			shot, err := parseShot(fields)
+			if err != nil {
+				return err
+			}
+			if ourSalvo {
+				b.strategy.record(shot)
+				continue
+			}
+			if pending == nil || shot.Position != *pending {
+				// the opponent's shot at us
+				continue
+			}
+			b.strategy.record(shot)
+			pending, rejected = nil, 0
+			fired++
+			if b.rules.Turns.TurnOver(b.rules, fired, shot) {
+				firing = false
+			}
+		case "ERROR":
+			log.Printf("[ai %s] %s", b.name, line)
+			if pending == nil && salvo == nil {
+				continue
+			}
+			// a shot turned down is not fired, the turn is still ours
+			rejected++
+			if rejected >= maxRejections {
+				if err := c.WriteLine("QUIT"); err != nil {
+					return err
+				}
+				return fmt.Errorf("resigned after %d rejected shots: %s", rejected, line)
+			}
+			if salvo != nil {
+				// the error does not tell which target was wrong, so fire
+				// a fresh salvo at random
+				for _, target := range salvo {
+					b.strategy.release(target)
+				}
+				var err error
+				salvo, err = b.fireSalvo(c, len(salvo), b.strategy.randomUnknown)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			// keep the target aimed, so it is not picked again
+			b.strategy.aim(*pending)
+			pending = nil
+		case "WIN", "ABANDONED":
+			return nil
+		default:
+			continue
+		}
+
+		if firing && pending == nil {
+			target := b.strategy.next()
+			pending = &target
+			if err := c.WriteLine(fmt.Sprintf("ATTACK %d %d", target.X, target.Y)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// turnShots reads how many shots a TURN grants.
+func turnShots(turn []string) int {
+	shots := 0
+	for _, field := range turn[2:] {
+		if value, found := strings.CutPrefix(field, "shots="); found {
+			fmt.Sscan(value, &shots)
+		}
+	}
+	return shots
+}
+
+// fireSalvo picks shots targets with pick and fires them all. It returns
+// the targets, which stay aimed until the outcome is known.
+func (b *Bot) fireSalvo(c *protocol.Conn, shots int, pick func() game.Vector2) ([]game.Vector2, error) {
+	targets := make([]game.Vector2, 0, shots)
+	salvo := "SALVO"
+	for range shots {
+		target := pick()
+		b.strategy.aim(target)
+		targets = append(targets, target)
+		salvo += fmt.Sprintf(" %d %d", target.X, target.Y)
+	}
+	return targets, c.WriteLine(salvo)
+}
+
+// setup introduces the bot and places its fleet.
+func (b *Bot) setup(c *protocol.Conn, lines <-chan string) error {
+	if err := c.WriteLine("HELLO " + b.name); err != nil {
+		return err
+	}
+	welcome, err := expect(lines, "WELCOME")
+	if err != nil {
+		return err
+	}
+	b.code = strings.Fields(welcome)[1]
+	size := game.DEFAULT_BOARD_SIZE
+	b.rules = b.presets[game.DEFAULT_RULESET]
+	for _, field := range strings.Fields(welcome) {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "size":
+			size, err = game.ParseBoardSize(value)
+		case "rules":
+			b.rules, err = b.presets.Lookup(value)
+		case "adjacency":
+			var adjacency game.AdjacencyPolicy
+			adjacency, err = game.ParseAdjacencyPolicy(value)
+			b.rules = b.rules.WithAdjacency(adjacency)
+		case "repeat":
+			var repeat game.RepeatPolicy
+			repeat, err = game.ParseRepeatPolicy(value)
+			b.rules = b.rules.WithRepeat(repeat)
+		case "turns":
+			var turns game.TurnPolicy
+			turns, err = game.ParseTurnPolicy(value)
+			b.rules = b.rules.WithTurns(turns)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	b.strategy = newStrategy(b.level, b.rng, b.rules, size)
+
+	placements, err := game.RandomPlacements(b.rng, b.rules, size)
+	if err != nil {
+		return err
+	}
+	for _, placement := range placements {
+		ship := fmt.Sprintf("SHIP %s %d %d %s", placement.ShipType, placement.X, placement.Y, placement.Direction)
+		if err := c.WriteLine(ship); err != nil {
+			return err
+		}
+		if _, err := expect(lines, "OK"); err != nil {
+			return err
+		}
+	}
+	return c.WriteLine("READY")
+}
+
+// expect waits for the reply to the last command.
+func expect(lines <-chan string, prefix string) (string, error) {
+	line, ok := <-lines
+	if !ok {
+		return "", fmt.Errorf("connection closed waiting for %s", prefix)
+	}
+	if !strings.HasPrefix(line, prefix) {
+		return "", fmt.Errorf("expected %s, got: %s", prefix, line)
+	}
+	return line, nil
+}
+
+// parseShot reads a HIT, MISS or SUNK broadcast.
+func parseShot(fields []string) (game.Shot, error) {
+	if len(fields) < 3 {
+		return game.Shot{}, fmt.Errorf("malformed shot: %s", strings.Join(fields, " "))
+	}
+	x, err := game.ParseCoordinate(fields[1])
+	if err != nil {
+		return game.Shot{}, err
+	}
+	y, err := game.ParseCoordinate(fields[2])
+	if err != nil {
+		return game.Shot{}, err
+	}
+
+	shot := game.Shot{Position: game.Vector2{X: x, Y: y}, Hit: fields[0] != "MISS"}
+	if fields[0] == "SUNK" && len(fields) > 3 {
+		shot.Sunk = game.ShipType(fields[3])
+	}
+	return shot, nil
+}
@@ -0,0 +1,318 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// Level selects how the AI picks its shots.
+type Level string
+
+const (
+	RANDOM  Level = "RANDOM"  // fire at any cell not shot yet
+	HUNT    Level = "HUNT"    // fire at random until a hit, then work around it
+	DENSITY Level = "DENSITY" // fire where the remaining ships most likely are
+)
+
+var ErrInvalidLevel = errors.New("invalid AI level")
+
+// ParseLevel converts a level sent by a client to a Level.
+func ParseLevel(s string) (Level, error) {
+	switch level := Level(s); level {
+	case RANDOM, HUNT, DENSITY:
+		return level, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrInvalidLevel, s)
+}
+
+type cellState int
+
+const (
+	unknown cellState = iota
+	miss
+	hit
+	sunk  // a hit known to belong to a sunk ship
+	aimed // part of a salvo still in flight
+)
+
+// strategy picks the next shot and learns from its outcome.
+type strategy interface {
+	next() game.Vector2
+	randomUnknown() game.Vector2
+	aim(target game.Vector2)
+	release(target game.Vector2)
+	record(shot game.Shot)
+}
+
+func newStrategy(level Level, rng *rand.Rand, rules *game.Ruleset, size int) strategy {
+	board := newBoard(rng, rules, size)
+	switch level {
+	case HUNT:
+		return &huntStrategy{board: board}
+	case DENSITY:
+		return &densityStrategy{board: board}
+	default:
+		return &randomStrategy{board: board}
+	}
+}
+
+// board is what the AI knows about the opponent's waters.
+type board struct {
+	rng       *rand.Rand
+	rules     *game.Ruleset
+	size      int
+	cells     [][]cellState         // indexed [x][y]
+	remaining map[game.ShipType]int // ships not sunk yet
+}
+
+func newBoard(rng *rand.Rand, rules *game.Ruleset, size int) *board {
+	remaining := make(map[game.ShipType]int)
+	for _, class := range rules.Ships {
+		remaining[class.Type] = class.Count
+	}
+	return &board{rng: rng, rules: rules, size: size, cells: newGrid[cellState](size), remaining: remaining}
+}
+
+func newGrid[T any](size int) [][]T {
+	grid := make([][]T, size)
+	for x := range grid {
+		grid[x] = make([]T, size)
+	}
+	return grid
+}
+
+func (b *board) at(position game.Vector2) cellState {
+	return b.cells[position.X][position.Y]
+}
+
+func (b *board) inBounds(position game.Vector2) bool {
+	return position.X >= 0 && position.X < b.size &&
+		position.Y >= 0 && position.Y < b.size
+}
+
+func (b *board) randomUnknown() game.Vector2 {
+	var candidates []game.Vector2
+	for x := range b.size {
+		for y := range b.size {
+			if b.cells[x][y] == unknown {
+				candidates = append(candidates, game.Vector2{X: x, Y: y})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		// nothing left to learn, but a repeat is only a wasted shot when
+		// repeats count as misses
+		if b.rules.Repeat == game.REPEAT_MISS {
+			return game.Vector2{X: b.rng.IntN(b.size), Y: b.rng.IntN(b.size)}
+		}
+		return game.Vector2{}
+	}
+	return candidates[b.rng.IntN(len(candidates))]
+}
+
+// aim keeps a target of a salvo from being picked again before its outcome
+// is known.
+func (b *board) aim(target game.Vector2) {
+	b.cells[target.X][target.Y] = aimed
+}
+
+// release makes a target of a salvo that was turned down free to be picked
+// again.
+func (b *board) release(target game.Vector2) {
+	if b.inBounds(target) && b.at(target) == aimed {
+		b.cells[target.X][target.Y] = unknown
+	}
+}
+
+func (b *board) record(shot game.Shot) {
+	position := shot.Position
+	if !shot.Hit {
+		b.cells[position.X][position.Y] = miss
+		return
+	}
+	b.cells[position.X][position.Y] = hit
+	if shot.Sunk != "" {
+		b.remaining[shot.Sunk]--
+		b.resolve(shot)
+	}
+}
+
+// resolve marks the cells of a ship that was just sunk, so they stop
+// attracting shots. Ships may touch, so this picks the first layout of the
+// sunk ship that covers the final shot and only known hits.
+func (b *board) resolve(shot game.Shot) {
+	class, _ := b.rules.Class(shot.Sunk)
+	for x := range b.size {
+		for y := range b.size {
+			for _, direction := range class.Orientations() {
+				cells, err := b.rules.ShipCells(shot.Sunk, x, y, direction, b.size)
+				if err != nil || !b.covers(cells, shot.Position) {
+					continue
+				}
+				if b.allHit(cells) {
+					for _, cell := range cells {
+						b.cells[cell.X][cell.Y] = sunk
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// blocked tells whether the adjacency policy rules out a ship at position,
+// because it would touch a ship that was sunk.
+func (b *board) blocked(position game.Vector2) bool {
+	for _, offset := range b.rules.Adjacency.Neighbours() {
+		neighbour := game.Vector2{X: position.X + offset.X, Y: position.Y + offset.Y}
+		if b.inBounds(neighbour) && b.at(neighbour) == sunk {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *board) covers(cells []game.Vector2, position game.Vector2) bool {
+	for _, cell := range cells {
+		if cell == position {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *board) allHit(cells []game.Vector2) bool {
+	for _, cell := range cells {
+		if b.at(cell) != hit {
+			return false
+		}
+	}
+	return true
+}
+
+// randomStrategy fires at random.
+type randomStrategy struct {
+	*board
+}
+
+func (s *randomStrategy) next() game.Vector2 {
+	return s.randomUnknown()
+}
+
+// huntStrategy fires at random until it hits something, then tries the
+// neighbours of every open hit until the ship goes down.
+type huntStrategy struct {
+	*board
+	targets []game.Vector2
+}
+
+func (s *huntStrategy) next() game.Vector2 {
+	for len(s.targets) > 0 {
+		target := s.targets[len(s.targets)-1]
+		s.targets = s.targets[:len(s.targets)-1]
+		if s.inBounds(target) && s.at(target) == unknown && !s.blocked(target) {
+			return target
+		}
+	}
+	return s.randomUnknown()
+}
+
+func (s *huntStrategy) record(shot game.Shot) {
+	s.board.record(shot)
+	if !shot.Hit {
+		return
+	}
+
+	// rebuild the target list from the hits that are still open
+	s.targets = s.targets[:0]
+	for x := range s.size {
+		for y := range s.size {
+			if s.cells[x][y] != hit {
+				continue
+			}
+			for _, d := range []game.Vector2{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}} {
+				s.targets = append(s.targets, game.Vector2{X: x + d.X, Y: y + d.Y})
+			}
+		}
+	}
+}
+
+// densityStrategy counts, for every cell, how many layouts of the remaining
+// ships could cover it, and fires at the most likely one. Layouts through
+// open hits weigh a lot more, which finishes off damaged ships first.
+type densityStrategy struct {
+	*board
+}
+
+const hitWeight = 20
+
+func (s *densityStrategy) next() game.Vector2 {
+	density := newGrid[int](s.size)
+
+	for _, class := range s.rules.Ships {
+		shipType, count := class.Type, s.remaining[class.Type]
+		if count <= 0 {
+			continue
+		}
+		for x := range s.size {
+			for y := range s.size {
+				for _, direction := range class.Orientations() {
+					cells, err := s.rules.ShipCells(shipType, x, y, direction, s.size)
+					if err != nil {
+						continue
+					}
+					weight, ok := s.weigh(cells)
+					if !ok {
+						continue
+					}
+					for _, cell := range cells {
+						density[cell.X][cell.Y] += weight * count
+					}
+				}
+			}
+		}
+	}
+
+	best := -1
+	var candidates []game.Vector2
+	for x := range s.size {
+		for y := range s.size {
+			if s.cells[x][y] != unknown {
+				continue
+			}
+			switch {
+			case density[x][y] > best:
+				best = density[x][y]
+				candidates = []game.Vector2{{X: x, Y: y}}
+			case density[x][y] == best:
+				candidates = append(candidates, game.Vector2{X: x, Y: y})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return s.randomUnknown()
+	}
+	return candidates[s.rng.IntN(len(candidates))]
+}
+
+// weigh rates a possible ship layout, which is impossible if it crosses a
+// miss or a ship that is already sunk, or touches one where that is not
+// allowed.
+func (s *densityStrategy) weigh(cells []game.Vector2) (int, bool) {
+	weight := 1
+	for _, cell := range cells {
+		if s.blocked(cell) {
+			return 0, false
+		}
+		switch s.at(cell) {
+		case miss, sunk:
+			return 0, false
+		case hit:
+			weight *= hitWeight
+		}
+	}
+	return weight, true
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/rivo/tview"
+)
+
+// paintCell draws what is known of a cell of a grid: x and y are the board
+// coordinates, column and row of the table.
+func paintCell(t *tview.Table, x int, y int, ship bool, cell game.Cell) {
+	mark, color := " ", tcell.ColorDimGray
+	if ship {
+		color = tcell.ColorSteelBlue
+	}
+	switch cell {
+	case game.CELL_MISS:
+		mark = "o"
+	case game.CELL_HIT:
+		mark, color = "X", tcell.ColorRed
+	case game.CELL_SUNK:
+		mark, color = "#", tcell.ColorDarkRed
+	}
+	t.GetCell(y, x).SetText(fmt.Sprintf("  %s  ", mark)).SetBackgroundColor(color)
+}
+
+// drawFleet paints a whole grid from the ships on it, as far as they are
+// known, and the shots it received.
+func drawFleet(t *tview.Table, rules *game.Ruleset, boardSize int, placements []game.Placement, shots [][]game.Cell) {
+	ships := make(map[game.Vector2]bool)
+	for _, placement := range placements {
+		cells, err := rules.ShipCells(placement.ShipType, placement.X, placement.Y, placement.Direction, boardSize)
+		if err != nil {
+			continue
+		}
+		for _, cell := range cells {
+			ships[cell] = true
+		}
+	}
+	for y := range boardSize {
+		for x := range boardSize {
+			paintCell(t, x, y, ships[game.Vector2{X: x, Y: y}], shots[y][x])
+		}
+	}
+}
+
+// clearGrid paints every cell of a grid as unknown water.
+func clearGrid(t *tview.Table, boardSize int) {
+	for y := range boardSize {
+		for x := range boardSize {
+			paintCell(t, x, y, false, game.CELL_UNKNOWN)
+		}
+	}
+}
+
+// paintGhost paints a ship that is being placed over a grid, green if it
+// may go there and red if not. Cells off the board are left out.
+func paintGhost(t *tview.Table, cells []game.Vector2, boardSize int, valid bool) {
+	color := tcell.ColorGreen
+	if !valid {
+		color = tcell.ColorRed
+	}
+	for _, cell := range cells {
+		if cell.X >= 0 && cell.X < boardSize && cell.Y >= 0 && cell.Y < boardSize {
+			t.GetCell(cell.Y, cell.X).SetBackgroundColor(color)
+		}
+	}
+}
+
+// cellName names a cell the way the grids are labelled, e.g. A1 for 0, 0.
+func cellName(cell game.Vector2) string {
+	return fmt.Sprintf("%c%d", 'A'+cell.X, cell.Y+1)
+}
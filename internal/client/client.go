@@ -5,21 +5,22 @@ import (
 	"net"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/protocol"
 	"github.com/rivo/tview"
 )
 
-const (
-	// PlayerGridSize is the size of the player grid
-	GridSize = 10
-)
-
 type Client struct {
-	app  *tview.Application
-	conn *net.Conn
+	app       *tview.Application
+	conn      *protocol.Conn
+	boardSize int // sent by the server in WELCOME
 	// state        *GameState
 	playerGrid   *tview.Table
 	opponentGrid *tview.Table
 	statusView   *tview.TextView
+	replay       *replayViewer // set when showing a match log instead of playing
+	match        *match        // set by WELCOME
+	layouts      *layoutBar    // set when playing
 }
 
 func NewClient() (*Client, error) {
@@ -28,17 +29,23 @@ func NewClient() (*Client, error) {
 		return nil, err
 	}
 
-	app := tview.NewApplication()
-	client := &Client{
-		app:  app,
-		conn: &conn,
+	client := newClient(protocol.NewConn(conn), game.DEFAULT_BOARD_SIZE)
+	client.setupUI()
+	client.setupPlay()
+	go client.read()
+	return client, nil
+}
+
+func newClient(conn *protocol.Conn, boardSize int) *Client {
+	return &Client{
+		app:       tview.NewApplication(),
+		conn:      conn,
+		boardSize: boardSize,
 		// state:        &GameState{Player: player, Status: "Connecting..."},
 		playerGrid:   tview.NewTable(),
 		opponentGrid: tview.NewTable(),
 		statusView:   tview.NewTextView(),
 	}
-	client.setupUI()
-	return client, nil
 }
 
 func (c *Client) setupStatusView() {
@@ -47,17 +54,35 @@ func (c *Client) setupStatusView() {
 	tv.SetDynamicColors(true)
 	tv.SetRegions(true)
 
-	tv.SetChangedFunc(func() {
-		c.app.Draw()
-	})
-
 	tv.SetTextAlign(tview.AlignCenter)
 	tv.SetBorder(true)
 	tv.SetTitle("Status")
 
+	if c.replay != nil {
+		tv.SetTitle("Replay")
+		return
+	}
 	fmt.Fprintf(tv, "\nSet up fleet\n")
 }
 
+// setupStatusRow puts the controls of the current mode, if any, next to the
+// status view.
+func (c *Client) setupStatusRow() tview.Primitive {
+	switch {
+	case c.replay != nil:
+		return tview.NewFlex().
+			SetDirection(tview.FlexColumn).
+			AddItem(c.statusView, 0, 1, false).
+			AddItem(c.replay.turnInput, 20, 0, false)
+	case c.layouts != nil:
+		return tview.NewFlex().
+			SetDirection(tview.FlexColumn).
+			AddItem(c.statusView, 0, 1, false).
+			AddItem(c.layouts.row, 36, 0, false)
+	}
+	return c.statusView
+}
+
 func newTableCell() *tview.TableCell {
 	cell := tview.NewTableCell("     ")
 	cell.SetAlign(tview.AlignCenter)
@@ -66,13 +91,14 @@ func newTableCell() *tview.TableCell {
 	return cell
 }
 
-func setupGrid(t *tview.Table) {
+func setupGrid(t *tview.Table, size int) {
+	t.Clear()
 	t.SetBorders(true)
 	// t.SetBorder(true)
-	t.SetFixed(GridSize, GridSize)
+	t.SetFixed(size, size)
 
-	for row := 0; row < GridSize; row++ {
-		for col := 0; col < GridSize; col++ {
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
 			cell := newTableCell()
 			t.SetCell(row, col, cell)
 		}
@@ -80,57 +106,57 @@ func setupGrid(t *tview.Table) {
 }
 
 func (c *Client) setupPlayerGrid() {
-	setupGrid(c.playerGrid)
+	setupGrid(c.playerGrid, c.boardSize)
 	// c.playerGrid.SetTitle("Player Grid")
 }
 
 func (c *Client) setupOpponentGrid() {
-	setupGrid(c.opponentGrid)
+	setupGrid(c.opponentGrid, c.boardSize)
 	// c.opponentGrid.SetTitle("Opponent Grid")
 }
 
-func setupLetterLabels() *tview.Table {
+func setupLetterLabels(size int) *tview.Table {
 	const cellWidth = 2
-	// Create letter labels (A-J) for left table (above)
+	// Create letter labels (A, B, ...) for left table (above)
 	letterLabels := tview.NewTable()
 	col := 1
-	for i := 0; i < 10; i++ {
+	for i := 0; i < size; i++ {
 		col = col + 5
 		letterLabels.SetCell(0, col, tview.NewTableCell(string(rune('A'+i))).
 			SetAlign(tview.AlignCenter).
 			SetMaxWidth(cellWidth))
 	}
-	letterLabels.SetFixed(1, 10) // Fix 1 row, 10 columns
+	letterLabels.SetFixed(1, size) // Fix 1 row, one column per letter
 	// letterLabels.SetBorder(true)
 
 	return letterLabels
 }
 
-func setupNumberLabels() *tview.Table {
+func setupNumberLabels(size int) *tview.Table {
 	const cellWidth = 2
-	// Create number labels (1-10) for top table (left)
+	// Create number labels (1, 2, ...) for top table (left)
 	numberLabels := tview.NewTable()
 	row := -1
-	for i := 0; i < 10; i++ {
+	for i := 0; i < size; i++ {
 		row = row + 2
 		numberLabels.SetCell(row, 0, tview.NewTableCell(fmt.Sprintf("%d", i+1)).
 			SetAlign(tview.AlignCenter).
 			SetMaxWidth(cellWidth))
 	}
-	numberLabels.SetFixed(10, 1) // Fix 10 rows, 1 column
+	numberLabels.SetFixed(size, 1) // Fix one row per number, 1 column
 	// numberLabels.SetBorder(true)
 
 	return numberLabels
 }
 
 func (c *Client) setupFirstRow() *tview.Flex {
-	const tableWidth = 63
-	const cellWidth = 4
+	// every cell takes 5 columns plus a border
+	tableWidth := c.boardSize*6 + 3
 
 	// come above
-	letterLabels := setupLetterLabels()
+	letterLabels := setupLetterLabels(c.boardSize)
 	// come left
-	numberLabels := setupNumberLabels()
+	numberLabels := setupNumberLabels(c.boardSize)
 
 	leftTableContainer := tview.NewFlex().
 		SetDirection(tview.FlexColumn).
@@ -182,14 +208,24 @@ func (c *Client) setupUI() {
 
 	mainFlex := tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(firstRow, 0, 1, false).      // First row with tables
-		AddItem(c.statusView, 3+2, 1, false) // Status view
+		AddItem(firstRow, 0, 1, false).            // First row with tables
+		AddItem(c.setupStatusRow(), 3+2, 1, false) // Status view
 	mainFlex.SetBorder(true).SetTitle("Main Layout")
 
 	c.app.SetRoot(mainFlex, true)
 }
 
+// SetBoardSize redraws both grids for the board size the server announced.
+func (c *Client) SetBoardSize(size int) {
+	c.boardSize = size
+	c.statusView.Clear()
+	c.setupUI()
+}
+
 func (c *Client) Run() error {
+	if c.replay != nil {
+		defer c.replay.close()
+	}
 	if err := c.app.Run(); err != nil {
 		return err
 	}
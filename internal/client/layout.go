@@ -0,0 +1,295 @@
+package client
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/rivo/tview"
+)
+
+// A layout is a fleet saved to place again in later games. Layouts are plain
+// text files named <name>.layout in layoutDir, one ship per line in the
+// words of a SHIP command. Blank lines and lines starting with # are
+// ignored; the header only says what the layout was made for:
+//
+//	# battleship layout, rules=standard size=10
+//	CARRIER 1 1 H
+//	CRUISER 5 0 V
+//	...
+//
+// A layout is checked against the rules of the game before it is sent, in
+// a single FLEET.
+const (
+	layoutExtension = ".layout"
+)
+
+var (
+	errInvalidLayout     = errors.New("invalid layout")
+	errInvalidLayoutName = errors.New("layout names may only hold letters, digits, - and _")
+)
+
+var layoutName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// layoutDir is where layouts are saved, in the user's configuration.
+func layoutDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "battleship", "layouts"), nil
+}
+
+func layoutPath(name string) (string, error) {
+	if !layoutName.MatchString(name) {
+		return "", fmt.Errorf("%w: %q", errInvalidLayoutName, name)
+	}
+	dir, err := layoutDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+layoutExtension), nil
+}
+
+// saveLayout writes placements as the layout name, replacing it if it
+// exists.
+func saveLayout(name string, rules *game.Ruleset, boardSize int, placements []game.Placement) error {
+	path, err := layoutPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	var layout strings.Builder
+	fmt.Fprintf(&layout, "# battleship layout, rules=%s size=%d\n", rules.Name, boardSize)
+	for _, placement := range placements {
+		fmt.Fprintf(&layout, "%s %d %d %s\n", placement.ShipType, placement.X, placement.Y, placement.Direction)
+	}
+	return os.WriteFile(path, []byte(layout.String()), 0o644)
+}
+
+// loadLayout reads the layout name.
+func loadLayout(name string) ([]game.Placement, error) {
+	path, err := layoutPath(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseLayout(file)
+}
+
+func parseLayout(r io.Reader) ([]game.Placement, error) {
+	var placements []game.Placement
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("%w: line %d: expected <type> <x> <y> <direction>, got %q", errInvalidLayout, line, text)
+		}
+		x, err := game.ParseCoordinate(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", errInvalidLayout, line, err)
+		}
+		y, err := game.ParseCoordinate(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", errInvalidLayout, line, err)
+		}
+		placements = append(placements, game.Placement{ShipType: game.ShipType(fields[0]), X: x, Y: y, Direction: fields[3]})
+	}
+	return placements, scanner.Err()
+}
+
+// listLayouts returns the names of the saved layouts.
+func listLayouts() []string {
+	dir, err := layoutDir()
+	if err != nil {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*"+layoutExtension))
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = strings.TrimSuffix(filepath.Base(path), layoutExtension)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// layoutBar holds the controls to place a whole fleet at once: auto-place,
+// save and load, with the name of the layout typed in nameInput.
+type layoutBar struct {
+	nameInput *tview.InputField
+	row       *tview.Flex
+	done      func(name string) // what Enter in nameInput does
+}
+
+func (c *Client) setupLayoutBar() {
+	bar := &layoutBar{nameInput: tview.NewInputField()}
+	c.layouts = bar
+
+	bar.nameInput.SetLabel("Name: ")
+	bar.nameInput.SetFieldWidth(16)
+	bar.nameInput.SetDoneFunc(func(key tcell.Key) {
+		done := bar.done
+		bar.done = nil
+		c.focusBoard()
+		if key == tcell.KeyEnter && done != nil && bar.nameInput.GetText() != "" {
+			done(bar.nameInput.GetText())
+		} else if c.match != nil {
+			c.showPlacement()
+		}
+	})
+
+	buttons := tview.NewFlex().
+		AddItem(tview.NewButton("Auto (a)").SetSelectedFunc(c.autoPlace), 0, 1, false).
+		AddItem(nil, 1, 0, false).
+		AddItem(tview.NewButton("Save (s)").SetSelectedFunc(c.askSaveLayout), 0, 1, false).
+		AddItem(nil, 1, 0, false).
+		AddItem(tview.NewButton("Load (l)").SetSelectedFunc(c.askLoadLayout), 0, 1, false)
+	bar.row = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(bar.nameInput, 1, 0, false).
+		AddItem(nil, 1, 0, false).
+		AddItem(buttons, 1, 0, false)
+	bar.row.SetBorder(true)
+	bar.row.SetTitle("Fleet")
+}
+
+// handleLayoutKey gives the buttons of the layout bar their shortcuts while
+// a grid has the focus.
+func (c *Client) handleLayoutKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Rune() {
+	case 'a', 'A':
+		c.autoPlace()
+	case 's', 'S':
+		c.askSaveLayout()
+	case 'l', 'L':
+		c.askLoadLayout()
+	default:
+		return event
+	}
+	return nil
+}
+
+// focusBoard hands the keyboard back to the grid being played on, after a
+// button or nameInput took it.
+func (c *Client) focusBoard() {
+	if c.match == nil || c.match.editor.shipType != "" {
+		c.app.SetFocus(c.playerGrid)
+		return
+	}
+	c.app.SetFocus(c.opponentGrid)
+}
+
+// placingFleet tells whether the fleet can still be placed.
+func (m *match) placingFleet() bool {
+	return m.editor.shipType != "" && m.placing == nil && m.sentFleet == nil && !m.autoPlacing
+}
+
+// autoPlace sends a random legal fleet. The layout is looked for off the UI
+// goroutine, as it takes a while when the fleet barely fits the board.
+func (c *Client) autoPlace() {
+	m := c.match
+	c.focusBoard()
+	if m == nil || !m.placingFleet() {
+		return
+	}
+	m.autoPlacing = true
+	c.setStatus("Looking for a layout...")
+	go func() {
+		rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+		placements, err := game.RandomPlacements(rng, m.rules, len(m.ourBoard))
+		c.app.QueueUpdateDraw(func() {
+			m.autoPlacing = false
+			if err != nil {
+				c.setStatus("[red]%v[-]", err)
+				return
+			}
+			c.sendFleet(placements)
+		})
+	}()
+}
+
+func (c *Client) askSaveLayout() {
+	m := c.match
+	if m == nil || len(m.fleet.Fleet.Placements()) != m.rules.ShipCount() {
+		c.setStatus("[red]Place the whole fleet before saving it[-]")
+		c.focusBoard()
+		return
+	}
+	c.askLayoutName("Save the fleet as", func(name string) {
+		if err := saveLayout(name, m.rules, len(m.ourBoard), m.fleet.Fleet.Placements()); err != nil {
+			c.setStatus("[red]%v[-]", err)
+			return
+		}
+		c.setStatus("Fleet saved as %s", name)
+	})
+}
+
+func (c *Client) askLoadLayout() {
+	m := c.match
+	if m == nil || !m.placingFleet() {
+		c.focusBoard()
+		return
+	}
+	names := listLayouts()
+	if len(names) == 0 {
+		c.setStatus("[red]No layouts saved yet[-]")
+		c.focusBoard()
+		return
+	}
+	c.askLayoutName("Load one of "+strings.Join(names, ", "), func(name string) {
+		placements, err := loadLayout(name)
+		if err != nil {
+			c.setStatus("[red]%v[-]", err)
+			return
+		}
+		c.sendFleet(placements)
+	})
+}
+
+func (c *Client) askLayoutName(prompt string, done func(name string)) {
+	c.layouts.done = done
+	c.layouts.nameInput.SetText("")
+	c.app.SetFocus(c.layouts.nameInput)
+	c.setStatus("%s: type a name and press Enter, Esc to cancel", prompt)
+}
+
+// sendFleet checks a whole fleet the way the server will and sends it in a
+// single FLEET.
+func (c *Client) sendFleet(placements []game.Placement) {
+	m := c.match
+	check := game.NewGame()
+	check.BoardSize = len(m.ourBoard)
+	check.Rules = m.rules
+	if err := check.AddPlayer(0, 0, "").PlaceFleet(placements); err != nil {
+		c.setStatus("[red]%v[-]", err)
+		return
+	}
+
+	fleet := "FLEET"
+	for _, placement := range placements {
+		fleet += fmt.Sprintf(" %s %d %d %s", placement.ShipType, placement.X, placement.Y, placement.Direction)
+	}
+	m.sentFleet = placements
+	c.send(fleet)
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// editor is the ship being placed: a ghost that follows the selected cell
+// of playerGrid until it is sent in SHIP.
+type editor struct {
+	shipType    game.ShipType // empty once the fleet is complete
+	orientation int           // index into the Orientations of its class
+	cursor      game.Vector2
+}
+
+// setupEditor lets the player move the ghost with the arrow keys or the
+// mouse, turn it with R, pick another ship type with Tab and place it with
+// Enter.
+func (c *Client) setupEditor() {
+	c.app.EnableMouse(true)
+	c.playerGrid.SetSelectable(true, true)
+	c.playerGrid.SetSelectionChangedFunc(func(row, column int) {
+		if c.match == nil {
+			return
+		}
+		c.match.editor.cursor = game.Vector2{X: column, Y: row}
+		c.showPlacement()
+	})
+	c.playerGrid.SetSelectedFunc(func(row, column int) {
+		c.place()
+	})
+	c.playerGrid.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if c.match == nil {
+			return event
+		}
+		if c.match.editor.shipType == "" {
+			return c.handleLayoutKey(event)
+		}
+		switch {
+		case event.Key() == tcell.KeyTab:
+			c.cycleShip(1)
+		case event.Key() == tcell.KeyBacktab:
+			c.cycleShip(-1)
+		case event.Rune() == 'r' || event.Rune() == 'R':
+			e := &c.match.editor
+			e.orientation = (e.orientation + 1) % len(c.orientations(e.shipType))
+			c.showPlacement()
+		default:
+			return c.handleLayoutKey(event)
+		}
+		return nil
+	})
+}
+
+func (c *Client) orientations(shipType game.ShipType) []string {
+	class, _ := c.match.rules.Class(shipType)
+	return class.Orientations()
+}
+
+// ghost is where the ship being placed would go.
+func (m *match) ghost() game.Placement {
+	class, _ := m.rules.Class(m.editor.shipType)
+	return game.Placement{
+		ShipType:  m.editor.shipType,
+		X:         m.editor.cursor.X,
+		Y:         m.editor.cursor.Y,
+		Direction: class.Orientations()[m.editor.orientation],
+	}
+}
+
+// remaining counts the ships of a type that still have to be placed.
+func (m *match) remaining(class game.ShipClass) int {
+	placed := 0
+	for _, placement := range m.fleet.Fleet.Placements() {
+		if placement.ShipType == class.Type {
+			placed++
+		}
+	}
+	return class.Count - placed
+}
+
+// nextShip keeps the ship type being placed while there are more of it, or
+// moves on to the next one. It sends READY once the fleet is complete.
+func (c *Client) nextShip() {
+	m := c.match
+	if class, _ := m.rules.Class(m.editor.shipType); m.remaining(class) == 0 {
+		c.cycleShip(1)
+	}
+	if m.editor.shipType == "" {
+		c.draw()
+		c.send("READY")
+		c.setStatus("Fleet ready, waiting for the opponent...")
+		return
+	}
+	c.showPlacement()
+}
+
+// cycleShip picks the next ship type, in ruleset order, that still has
+// ships to place.
+func (c *Client) cycleShip(step int) {
+	m := c.match
+	ships := m.rules.Ships
+	current := -1
+	for i, class := range ships {
+		if class.Type == m.editor.shipType {
+			current = i
+		}
+	}
+	if current == -1 && step < 0 {
+		current = 0
+	}
+
+	m.editor.shipType = ""
+	for i := 1; i <= len(ships); i++ {
+		class := ships[((current+step*i)%len(ships)+len(ships))%len(ships)]
+		if m.remaining(class) > 0 {
+			m.editor.shipType, m.editor.orientation = class.Type, 0
+			break
+		}
+	}
+	if m.editor.shipType != "" {
+		c.showPlacement()
+	}
+}
+
+// place sends the ghost in SHIP, unless it is already clear it would be
+// refused.
+func (c *Client) place() {
+	m := c.match
+	if m == nil || !m.placingFleet() {
+		return
+	}
+	placement := m.ghost()
+	if err := m.fleet.CheckShip(placement); err != nil {
+		return
+	}
+	m.placing = &placement
+	c.send(fmt.Sprintf("SHIP %s %d %d %s", placement.ShipType, placement.X, placement.Y, placement.Direction))
+}
+
+// drawGhost paints the ghost over playerGrid, in red where it may not go.
+func (c *Client) drawGhost() {
+	m := c.match
+	placement := m.ghost()
+	class, _ := m.rules.Class(placement.ShipType)
+	offsets, err := class.Cells(placement.Direction)
+	if err != nil {
+		return
+	}
+	cells := make([]game.Vector2, len(offsets))
+	for i, offset := range offsets {
+		cells[i] = game.Vector2{X: placement.X + offset.X, Y: placement.Y + offset.Y}
+	}
+	paintGhost(c.playerGrid, cells, len(m.ourBoard), m.fleet.CheckShip(placement) == nil)
+}
+
+// showPlacement redraws the ghost and tells what is left to place and why
+// the ghost may not go where it is.
+func (c *Client) showPlacement() {
+	m := c.match
+	if m.editor.shipType == "" {
+		return
+	}
+	c.draw()
+
+	var ships []string
+	for _, class := range m.rules.Ships {
+		ship := fmt.Sprintf("%s %d", class.Type, m.remaining(class))
+		if class.Type == m.editor.shipType {
+			ship = fmt.Sprintf("[yellow]%s %s[-]", ship, m.ghost().Direction)
+		}
+		ships = append(ships, ship)
+	}
+
+	help := "arrows or mouse: move, R: rotate, Tab: ship type, Enter: place, A: auto-place all"
+	if err := m.fleet.CheckShip(m.ghost()); err != nil {
+		help = fmt.Sprintf("[red]%v[-]", err)
+	}
+	c.setStatus("You are %s, left to place: %s\n%s", m.code, strings.Join(ships, ", "), help)
+}
@@ -0,0 +1,387 @@
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/rivo/tview"
+)
+
+// match is what the client knows of the game it plays. It is only touched
+// from the UI goroutine.
+type match struct {
+	code        string        // our player code, from WELCOME
+	rules       *game.Ruleset // from WELCOME
+	fleet       *game.Player  // our fleet as the server accepted it, to check placements against
+	editor      editor
+	placing     *game.Placement  // sent, waiting for OK SHIP
+	sentFleet   []game.Placement // sent in FLEET, waiting for OK FLEET
+	autoPlacing bool             // looking for a random fleet
+	ourBoard    [][]game.Cell    // the opponent's shots at our fleet
+	theirBoard  [][]game.Cell    // our shots at the opponent's fleet
+	myTurn      bool
+	fired       int            // shots of this turn
+	shots       int            // shots a salvo must have, 0 unless salvo turns
+	targets     []game.Vector2 // of the salvo being aimed
+	pending     *game.Vector2  // sent in ATTACK, waiting for the result
+	salvoSent   bool           // waiting for the result of our SALVO
+	shooter     string         // player whose SALVO, STRIKE or TORPEDO is being reported
+	over        bool
+}
+
+func newBoard(size int) [][]game.Cell {
+	board := make([][]game.Cell, size)
+	for y := range board {
+		board[y] = make([]game.Cell, size)
+	}
+	return board
+}
+
+// maxNameLength is the longest name the server takes in HELLO, in bytes.
+const maxNameLength = 20
+
+// setupPlay asks for the player's name and hooks the grids up to the game.
+func (c *Client) setupPlay() {
+	c.setupEditor()
+	c.setupLayoutBar()
+	c.opponentGrid.SetSelectable(true, true)
+	c.opponentGrid.SetSelectedFunc(func(row, column int) {
+		c.aim(column, row)
+	})
+	c.opponentGrid.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if c.match == nil {
+			return event
+		}
+		return c.handleLayoutKey(event)
+	})
+	c.promptName("")
+}
+
+// promptName asks for the player's name and says HELLO with it. refused is
+// why the server turned the last name down, if it did.
+func (c *Client) promptName(refused string) {
+	name := tview.NewInputField()
+	name.SetLabel("Name: ")
+	name.SetFieldWidth(maxNameLength)
+	name.SetAcceptanceFunc(func(text string, last rune) bool {
+		return !strings.ContainsRune(text, ' ') && len(text) <= maxNameLength
+	})
+	name.SetBorder(true)
+	name.SetTitle("Battleship")
+	name.SetDoneFunc(func(key tcell.Key) {
+		if key != tcell.KeyEnter || name.GetText() == "" {
+			return
+		}
+		c.setupUI()
+		c.send("HELLO " + name.GetText())
+		c.setStatus("Waiting for an opponent...")
+	})
+
+	refusal := tview.NewTextView().SetDynamicColors(true).SetWrap(true)
+	if refused != "" {
+		fmt.Fprintf(refusal, "[red]%s[-]", refused)
+	}
+
+	prompt := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().
+			SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(name, 3, 0, true).
+			AddItem(refusal, 2, 0, false).
+			AddItem(nil, 0, 1, false), 30, 0, true).
+		AddItem(nil, 0, 1, false)
+	c.app.SetRoot(prompt, true)
+	c.app.SetFocus(name)
+}
+
+// read hands every line from the server over to the UI goroutine, until
+// the connection is closed.
+func (c *Client) read() {
+	for {
+		line, err := c.conn.ReadLine()
+		if err != nil {
+			c.app.QueueUpdateDraw(func() {
+				if c.match == nil || !c.match.over {
+					c.setStatus("[red]Disconnected: %v[-]", err)
+				}
+			})
+			return
+		}
+		c.app.QueueUpdateDraw(func() {
+			c.handleLine(line)
+		})
+	}
+}
+
+func (c *Client) send(line string) {
+	if err := c.conn.WriteLine(line); err != nil {
+		c.setStatus("[red]%v[-]", err)
+	}
+}
+
+func (c *Client) setStatus(format string, args ...any) {
+	c.statusView.Clear()
+	fmt.Fprintf(c.statusView, "\n"+format+"\n", args...)
+}
+
+func (c *Client) handleLine(line string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return
+	}
+	if fields[0] == "WELCOME" {
+		c.welcome(fields)
+		return
+	}
+	m := c.match
+	if m == nil {
+		// before WELCOME, an error can only be the server refusing HELLO
+		if fields[0] == "ERROR" {
+			c.promptName(strings.Join(fields[1:], " "))
+		}
+		return
+	}
+
+	switch fields[0] {
+	case "OK":
+		if len(fields) > 1 && fields[1] == "SHIP" && m.placing != nil {
+			placement := *m.placing
+			m.placing = nil
+			m.fleet.AddShip(string(placement.ShipType), placement.X, placement.Y, placement.Direction)
+			c.nextShip()
+		}
+		if len(fields) > 1 && fields[1] == "FLEET" && m.sentFleet != nil {
+			m.fleet.PlaceFleet(m.sentFleet)
+			m.sentFleet = nil
+			c.nextShip()
+		}
+	case "ERROR":
+		m.placing = nil
+		m.sentFleet = nil
+		m.pending = nil
+		m.salvoSent = false
+		c.setStatus("[red]%s[-]", strings.Join(fields[1:], " "))
+	case "START":
+		c.setStatus("The game starts, %s plays first", fields[1])
+	case "TURN":
+		if fields[1] != m.code {
+			return
+		}
+		m.myTurn, m.fired, m.targets = true, 0, nil
+		for _, field := range fields[2:] {
+			if value, found := strings.CutPrefix(field, "shots="); found {
+				fmt.Sscan(value, &m.shots)
+			}
+		}
+		c.app.SetFocus(c.opponentGrid)
+		c.promptAttack()
+	case "SALVO", "STRIKE", "TORPEDO":
+		m.shooter = fields[1]
+	case "END":
+		if m.shooter == m.code && m.salvoSent {
+			m.myTurn, m.salvoSent = false, false
+		}
+		m.shooter = ""
+		c.draw()
+	case "HIT", "MISS", "SUNK":
+		c.recordShot(fields)
+	case "SONAR":
+		c.setStatus("Sonar of %s at %s: %s", fields[1], strings.Join(fields[2:4], " "), fields[4])
+	case "WIN":
+		m.over, m.myTurn = true, false
+		c.markWinningShot(fields)
+		c.draw()
+		result := "[red]You lose[-]"
+		if fields[1] == m.code {
+			result = "[green]You win[-]"
+		}
+		c.setStatus("%s %s", result, strings.Join(fields[2:], " "))
+	case "ABANDONED":
+		m.over = true
+		c.setStatus("The opponent left before the game started")
+	}
+}
+
+// welcome sets the game up as the server announced it and starts the fleet
+// placement.
+func (c *Client) welcome(fields []string) {
+	size := game.DEFAULT_BOARD_SIZE
+	rules := game.Rulesets[game.DEFAULT_RULESET]
+	var err error
+	for _, field := range fields {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "size":
+			size, err = game.ParseBoardSize(value)
+		case "rules":
+			rules, err = game.LookupRuleset(value)
+		case "adjacency":
+			var adjacency game.AdjacencyPolicy
+			adjacency, err = game.ParseAdjacencyPolicy(value)
+			rules = rules.WithAdjacency(adjacency)
+		case "repeat":
+			var repeat game.RepeatPolicy
+			repeat, err = game.ParseRepeatPolicy(value)
+			rules = rules.WithRepeat(repeat)
+		case "turns":
+			var turns game.TurnPolicy
+			turns, err = game.ParseTurnPolicy(value)
+			rules = rules.WithTurns(turns)
+		}
+		if err != nil {
+			c.setStatus("[red]%v[-]", err)
+			return
+		}
+	}
+
+	local := game.NewGame()
+	local.BoardSize = size
+	local.Rules = rules
+	c.match = &match{
+		code:       fields[1],
+		rules:      rules,
+		fleet:      local.AddPlayer(0, 0, ""),
+		ourBoard:   newBoard(size),
+		theirBoard: newBoard(size),
+	}
+	c.SetBoardSize(size)
+	c.app.SetFocus(c.playerGrid)
+	c.nextShip()
+}
+
+// aim fires at the selected cell, or adds it to the salvo and fires the
+// salvo once it is complete.
+func (c *Client) aim(x int, y int) {
+	m := c.match
+	if m == nil || !m.myTurn || m.pending != nil || m.salvoSent {
+		return
+	}
+	target := game.Vector2{X: x, Y: y}
+	if m.shots == 0 {
+		m.pending = &target
+		c.send(fmt.Sprintf("ATTACK %d %d", x, y))
+		return
+	}
+
+	m.targets = append(m.targets, target)
+	if len(m.targets) < m.shots {
+		c.promptAttack()
+		return
+	}
+	salvo := "SALVO"
+	for _, target := range m.targets {
+		salvo += fmt.Sprintf(" %d %d", target.X, target.Y)
+	}
+	m.targets = nil
+	m.salvoSent = true
+	c.send(salvo)
+}
+
+func (c *Client) promptAttack() {
+	m := c.match
+	if m.shots > 0 {
+		c.setStatus("Your turn: pick %d more cells for the salvo", m.shots-len(m.targets))
+		return
+	}
+	c.setStatus("Your turn: pick a cell on the opponent's grid and press Enter")
+}
+
+// recordShot puts a HIT, MISS or SUNK on the grid it was fired at.
+func (c *Client) recordShot(fields []string) {
+	m := c.match
+	if len(fields) < 3 {
+		return
+	}
+	var x, y int
+	if _, err := fmt.Sscan(fields[1]+" "+fields[2], &x, &y); err != nil {
+		return
+	}
+	position := game.Vector2{X: x, Y: y}
+	cell := game.CELL_MISS
+	switch fields[0] {
+	case "HIT":
+		cell = game.CELL_HIT
+	case "SUNK":
+		cell = game.CELL_SUNK
+	}
+
+	var ours bool
+	switch {
+	case m.shooter != "":
+		ours = m.shooter == m.code
+	case m.pending != nil && *m.pending == position:
+		ours = true
+		m.pending = nil
+		m.fired++
+		if m.rules.Turns.TurnOver(m.rules, m.fired, game.Shot{Position: position, Hit: cell != game.CELL_MISS}) {
+			m.myTurn = false
+		}
+	}
+
+	if ours {
+		m.theirBoard[y][x] = cell
+	} else {
+		m.ourBoard[y][x] = cell
+		if cell == game.CELL_SUNK {
+			c.sinkOwnShip(position)
+		}
+	}
+	c.draw()
+	if m.myTurn && m.shooter == "" {
+		c.promptAttack()
+	} else if !m.myTurn && m.shooter == "" {
+		c.setStatus("%s %s, waiting for the opponent...", fields[0], cellName(position))
+	}
+}
+
+// markWinningShot puts the shot that won the game on its grid, as the server
+// announces a winning ATTACK by WIN alone. A WIN with a reason was not won
+// by a shot.
+func (c *Client) markWinningShot(fields []string) {
+	m := c.match
+	if len(fields) > 2 {
+		return
+	}
+	if fields[1] == m.code {
+		if m.pending != nil {
+			m.theirBoard[m.pending.Y][m.pending.X] = game.CELL_SUNK
+			m.pending = nil
+		}
+		return
+	}
+	// the last cell of our fleet was hit, so all of it is sunk
+	for _, placement := range m.fleet.Fleet.Placements() {
+		cells, _ := m.rules.ShipCells(placement.ShipType, placement.X, placement.Y, placement.Direction, len(m.ourBoard))
+		for _, cell := range cells {
+			m.ourBoard[cell.Y][cell.X] = game.CELL_SUNK
+		}
+	}
+}
+
+// sinkOwnShip marks every cell of our ship at position as sunk.
+func (c *Client) sinkOwnShip(position game.Vector2) {
+	m := c.match
+	for _, placement := range m.fleet.Fleet.Placements() {
+		cells, _ := m.rules.ShipCells(placement.ShipType, placement.X, placement.Y, placement.Direction, len(m.ourBoard))
+		if !slices.Contains(cells, position) {
+			continue
+		}
+		for _, cell := range cells {
+			m.ourBoard[cell.Y][cell.X] = game.CELL_SUNK
+		}
+	}
+}
+
+func (c *Client) draw() {
+	m := c.match
+	size := len(m.ourBoard)
+	drawFleet(c.playerGrid, m.rules, size, m.fleet.Fleet.Placements(), m.ourBoard)
+	drawFleet(c.opponentGrid, m.rules, size, nil, m.theirBoard)
+	if m.editor.shipType != "" {
+		c.drawGhost()
+	}
+}
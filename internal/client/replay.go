@@ -0,0 +1,278 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/matchlog"
+	"github.com/rivo/tview"
+)
+
+const (
+	replayTick = 100 * time.Millisecond
+)
+
+// replaySpeeds are the delays between two steps while playing, slowest first.
+var replaySpeeds = []time.Duration{
+	2 * time.Second,
+	time.Second,
+	500 * time.Millisecond,
+	200 * time.Millisecond,
+	100 * time.Millisecond,
+}
+
+// replayViewer steps through a recorded match, P1's fleet on playerGrid and
+// P2's on opponentGrid. Its state is only touched from the UI goroutine.
+type replayViewer struct {
+	client    *Client
+	replay    *matchlog.Replay
+	step      int // steps shown, 0 is the game as it was set up
+	playing   bool
+	speed     int           // index into replaySpeeds
+	elapsed   time.Duration // since the last step while playing
+	turnInput *tview.InputField
+	err       error // why the current step could not be shown
+	ticker    *time.Ticker
+	done      chan struct{} // closed with the viewer, stops the ticking
+}
+
+// NewReplayClient opens a match log and shows it instead of connecting to a
+// server.
+func NewReplayClient(path string) (*Client, error) {
+	replay, err := matchlog.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// check the setup now rather than on the first step
+	if _, err := replay.Setup.NewGame(); err != nil {
+		return nil, err
+	}
+
+	client := newClient(nil, replay.Setup.BoardSize)
+	viewer := &replayViewer{
+		client:    client,
+		replay:    replay,
+		speed:     1,
+		turnInput: tview.NewInputField(),
+		ticker:    time.NewTicker(replayTick),
+		done:      make(chan struct{}),
+	}
+	client.replay = viewer
+	viewer.setupTurnInput()
+	client.setupUI()
+	client.app.SetInputCapture(viewer.handleKey)
+	viewer.show()
+
+	go func() {
+		for {
+			select {
+			case <-viewer.ticker.C:
+				client.app.QueueUpdateDraw(viewer.tick)
+			case <-viewer.done:
+				return
+			}
+		}
+	}()
+	return client, nil
+}
+
+// close stops the ticking once the viewer is gone.
+func (v *replayViewer) close() {
+	v.ticker.Stop()
+	close(v.done)
+}
+
+func (v *replayViewer) setupTurnInput() {
+	input := v.turnInput
+	input.SetLabel("Turn: ")
+	input.SetFieldWidth(6)
+	input.SetAcceptanceFunc(tview.InputFieldInteger)
+	input.SetBorder(true)
+	input.SetTitle("Jump (t)")
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			if turn, err := strconv.Atoi(input.GetText()); err == nil {
+				v.playing = false
+				v.seek(v.replay.StepAtTurn(turn))
+			}
+		}
+		input.SetText("")
+		v.client.app.SetFocus(v.client.playerGrid)
+	})
+}
+
+// handleKey drives the replay from the keyboard: arrows step, space plays
+// and pauses, + and - change the speed and t jumps to a turn.
+func (v *replayViewer) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	if v.turnInput.HasFocus() {
+		return event
+	}
+	switch event.Key() {
+	case tcell.KeyRight:
+		v.playing = false
+		v.seek(v.step + 1)
+		return nil
+	case tcell.KeyLeft:
+		v.playing = false
+		v.seek(v.step - 1)
+		return nil
+	case tcell.KeyHome:
+		v.seek(0)
+		return nil
+	case tcell.KeyEnd:
+		v.seek(len(v.replay.Steps))
+		return nil
+	}
+	switch event.Rune() {
+	case ' ':
+		v.playing = !v.playing && v.step < len(v.replay.Steps)
+		v.elapsed = 0
+	case '+':
+		v.speed = min(v.speed+1, len(replaySpeeds)-1)
+	case '-':
+		v.speed = max(v.speed-1, 0)
+	case 't':
+		v.playing = false
+		v.client.app.SetFocus(v.turnInput)
+	case 'q':
+		v.client.app.Stop()
+	default:
+		return event
+	}
+	v.show()
+	return nil
+}
+
+// tick moves the replay on while it is playing.
+func (v *replayViewer) tick() {
+	if !v.playing {
+		return
+	}
+	v.elapsed += replayTick
+	if v.elapsed < replaySpeeds[v.speed] {
+		return
+	}
+	v.elapsed = 0
+	v.seek(v.step + 1)
+	if v.step == len(v.replay.Steps) {
+		v.playing = false
+		v.show()
+	}
+}
+
+func (v *replayViewer) seek(step int) {
+	v.step = max(0, min(step, len(v.replay.Steps)))
+	v.show()
+}
+
+// show draws the game as it was after the current step. Both grids are
+// cleared first, so a player who had not joined yet shows an empty board.
+func (v *replayViewer) show() {
+	c := v.client
+	g, err := v.replay.GameAt(v.step)
+	v.err = err
+	for seat, grid := range []*tview.Table{c.playerGrid, c.opponentGrid} {
+		clearGrid(grid, c.boardSize)
+		if err != nil {
+			continue
+		}
+		if player := g.PlayerAt(seat); player != nil {
+			drawFleet(grid, g.Rules, g.BoardSize, player.Fleet.Placements(), player.Fleet.ShotGrid())
+		}
+	}
+
+	c.statusView.Clear()
+	fmt.Fprintf(c.statusView, "%s\n%s\n%s", v.position(), v.describe(), v.controls())
+}
+
+func (v *replayViewer) position() string {
+	turn := 1
+	if v.step > 0 {
+		turn = v.replay.Steps[v.step-1].Turn
+	}
+	return fmt.Sprintf("[yellow]Step %d/%d[-], turn %d, %s", v.step, len(v.replay.Steps), turn, v.replay.Setup.Rules)
+}
+
+func (v *replayViewer) describe() string {
+	if v.err != nil {
+		return fmt.Sprintf("[red]%v[-]", v.err)
+	}
+	if v.step == 0 {
+		return "Game set up"
+	}
+	step := v.replay.Steps[v.step-1]
+	var events []string
+	for _, event := range step.Events {
+		events = append(events, describeEvent(event))
+	}
+	return fmt.Sprintf("%s %s: %s", step.Time.Format(time.TimeOnly), step.Player, strings.Join(events, ", "))
+}
+
+func (v *replayViewer) controls() string {
+	state := "paused"
+	if v.playing {
+		state = fmt.Sprintf("playing, %v per step", replaySpeeds[v.speed])
+	}
+	return fmt.Sprintf("←/→ step, space play/pause, +/- speed, t turn, q quit (%s)", state)
+}
+
+// describeEvent says what happened in a few words, with the cells named the
+// way the grids are labelled.
+func describeEvent(event game.Event) string {
+	switch event := event.(type) {
+	case game.PlayerJoined:
+		return fmt.Sprintf("%s joined", event.Name)
+	case game.ShipPlaced:
+		return fmt.Sprintf("placed a %s at %s", event.Placement.ShipType, cellName(game.Vector2{X: event.Placement.X, Y: event.Placement.Y}))
+	case game.FleetPlaced:
+		return fmt.Sprintf("placed a fleet of %d", len(event.Placements))
+	case game.ShipRemoved:
+		return fmt.Sprintf("removed a %s", event.ShipType)
+	case game.FleetReset:
+		return "cleared the fleet"
+	case game.PlayerReady:
+		return "ready"
+	case game.GameStarted:
+		return "the game starts"
+	case game.TurnStarted:
+		return fmt.Sprintf("P%d to play", event.Seat+1)
+	case game.ShotFired:
+		return describeShots([]game.Shot{event.Shot})
+	case game.SalvoFired:
+		return "salvo " + describeShots(event.Shots)
+	case game.StrikeFired:
+		return fmt.Sprintf("strike at %s %s", cellName(event.Centre), describeShots(event.Shots))
+	case game.SonarPinged:
+		if event.Found {
+			return fmt.Sprintf("sonar at %s found a ship", cellName(event.Centre))
+		}
+		return fmt.Sprintf("sonar at %s found nothing", cellName(event.Centre))
+	case game.TorpedoFired:
+		return fmt.Sprintf("torpedo from %s %s", cellName(event.Start), describeShots(event.Shots))
+	case game.GameWon:
+		if event.Reason != "" {
+			return fmt.Sprintf("[green]P%d wins (%s)[-]", event.Seat+1, event.Reason)
+		}
+		return fmt.Sprintf("[green]P%d wins[-]", event.Seat+1)
+	}
+	return fmt.Sprintf("%T", event)
+}
+
+func describeShots(shots []game.Shot) string {
+	var results []string
+	for _, shot := range shots {
+		switch {
+		case shot.Sunk != "":
+			results = append(results, fmt.Sprintf("%s sunk %s", cellName(shot.Position), shot.Sunk))
+		case shot.Hit:
+			results = append(results, fmt.Sprintf("%s hit", cellName(shot.Position)))
+		default:
+			results = append(results, fmt.Sprintf("%s miss", cellName(shot.Position)))
+		}
+	}
+	return strings.Join(results, " ")
+}
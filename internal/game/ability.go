@@ -0,0 +1,164 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Ability is a special weapon a ship class grants. Every ship of the class
+// can use it once, as long as it is afloat.
+type Ability string
+
+const (
+	NO_ABILITY Ability = ""
+	STRIKE     Ability = "STRIKE"  // shoots a 3x3 area at once
+	SONAR      Ability = "SONAR"   // tells whether a 3x3 area holds any ship
+	TORPEDO    Ability = "TORPEDO" // runs along a row or column until it hits a ship
+)
+
+// ParseAbility reads an ability as named in a ruleset.
+func ParseAbility(s string) (Ability, error) {
+	switch ability := Ability(s); ability {
+	case NO_ABILITY, STRIKE, SONAR, TORPEDO:
+		return ability, nil
+	}
+	return NO_ABILITY, fmt.Errorf("%w: %s", ErrInvalidAbility, s)
+}
+
+// area returns the cells of the 3x3 square around centre that are on a board
+// of the given size.
+func area(centre Vector2, boardSize int) []Vector2 {
+	var cells []Vector2
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			cell := Vector2{centre.X + dx, centre.Y + dy}
+			if isValidCoordinate(cell.X, boardSize) && isValidCoordinate(cell.Y, boardSize) {
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return cells
+}
+
+// abilityShip returns a ship of the fleet that can still use ability.
+func (f *Fleet) abilityShip(ability Ability) (*Ship, bool) {
+	for _, class := range f.rules.Ships {
+		if class.Ability != ability {
+			continue
+		}
+		for _, ship := range f.ships[class.Type] {
+			if !ship.abilityUsed && !ship.isSunk() {
+				return ship, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// useAbility checks that attacker may use ability now and takes it from one
+// of their ships. The target of the ability must be checked before, as it
+// is consumed here.
+func (g *Game) useAbility(attacker *Player, ability Ability) error {
+	if g.Rules.Turns.Salvo() {
+		return fmt.Errorf("%w: %s, fire with SALVO", ErrTurnPolicy, g.Rules.Turns)
+	}
+	if !g.IsPlayersTurn(attacker) {
+		return ErrNotYourTurn
+	}
+	ship, exists := attacker.Fleet.abilityShip(ability)
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrAbilityUnavailable, ability)
+	}
+	ship.abilityUsed = true
+	return nil
+}
+
+// checkOnBoard tells why an ability cannot be aimed at cell, if it can't.
+func (g *Game) checkOnBoard(cell Vector2) error {
+	if !isValidCoordinate(cell.X, g.BoardSize) || !isValidCoordinate(cell.Y, g.BoardSize) {
+		return fmt.Errorf("%w: (%d, %d)", ErrOutOfBounds, cell.X, cell.Y)
+	}
+	return nil
+}
+
+// Strike shoots every cell of the 3x3 area around centre that was not shot
+// yet. It counts as one shot of the turn, a hit if any cell was hit.
+func (g *Game) Strike(attacker *Player, centre Vector2) ([]Shot, error) {
+	if err := g.checkOnBoard(centre); err != nil {
+		return nil, err
+	}
+	if err := g.useAbility(attacker, STRIKE); err != nil {
+		return nil, err
+	}
+	opponent := g.GetOtherPlayer(attacker.id)
+
+	var shots []Shot
+	hit := false
+	for _, cell := range area(centre, g.BoardSize) {
+		if opponent.Fleet.isShot(cell) {
+			continue
+		}
+		shot := opponent.Fleet.fire(cell)
+		hit = hit || shot.Hit
+		shots = append(shots, shot)
+	}
+	g.advanceTurn(attacker, Shot{Position: centre, Hit: hit})
+	return shots, nil
+}
+
+// Sonar tells whether the 3x3 area around centre holds a ship cell that was
+// not hit yet. It counts as one shot of the turn, and never as a hit.
+func (g *Game) Sonar(attacker *Player, centre Vector2) (bool, error) {
+	if err := g.checkOnBoard(centre); err != nil {
+		return false, err
+	}
+	if err := g.useAbility(attacker, SONAR); err != nil {
+		return false, err
+	}
+	opponent := g.GetOtherPlayer(attacker.id)
+
+	found := false
+	for _, cell := range area(centre, g.BoardSize) {
+		if _, exists := opponent.Fleet.getShipAtPosition(cell); exists && !opponent.Fleet.isShot(cell) {
+			found = true
+		}
+	}
+	g.advanceTurn(attacker, Shot{Position: centre})
+	return found, nil
+}
+
+// torpedoDirections are the steps a torpedo may take: one cell along a row
+// or a column.
+var torpedoDirections = []Vector2{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+
+// Torpedo runs from start in steps of direction, over cells that were shot
+// already, and explodes on the first ship cell that was not hit yet. Every
+// new cell it crosses is a miss. It counts as one shot of the turn.
+func (g *Game) Torpedo(attacker *Player, start Vector2, direction Vector2) ([]Shot, error) {
+	if err := g.checkOnBoard(start); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(torpedoDirections, direction) {
+		return nil, fmt.Errorf("%w: (%d, %d), a torpedo runs along a row or a column", ErrInvalidDirection, direction.X, direction.Y)
+	}
+	if err := g.useAbility(attacker, TORPEDO); err != nil {
+		return nil, err
+	}
+	opponent := g.GetOtherPlayer(attacker.id)
+
+	var shots []Shot
+	hit := false
+	for cell := start; g.checkOnBoard(cell) == nil; cell = (Vector2{cell.X + direction.X, cell.Y + direction.Y}) {
+		if opponent.Fleet.isShot(cell) {
+			continue
+		}
+		shot := opponent.Fleet.fire(cell)
+		shots = append(shots, shot)
+		if shot.Hit {
+			hit = true
+			break
+		}
+	}
+	g.advanceTurn(attacker, Shot{Position: start, Hit: hit})
+	return shots, nil
+}
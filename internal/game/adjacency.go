@@ -0,0 +1,64 @@
+package game
+
+import "fmt"
+
+// AdjacencyPolicy decides whether ships may touch each other.
+type AdjacencyPolicy int
+
+const (
+	ADJACENCY_ALLOWED    AdjacencyPolicy = iota // ships may touch, only overlap is forbidden
+	ADJACENCY_NO_EDGES                          // ships may only touch at the corners
+	ADJACENCY_NO_CONTACT                        // ships may not touch at all
+)
+
+var adjacencyNames = map[AdjacencyPolicy]string{
+	ADJACENCY_ALLOWED:    "allowed",
+	ADJACENCY_NO_EDGES:   "no-edges",
+	ADJACENCY_NO_CONTACT: "no-contact",
+}
+
+func (p AdjacencyPolicy) String() string {
+	if name, exists := adjacencyNames[p]; exists {
+		return name
+	}
+	return fmt.Sprintf("AdjacencyPolicy(%d)", int(p))
+}
+
+// ParseAdjacencyPolicy reads a policy by the name String gives it.
+func ParseAdjacencyPolicy(s string) (AdjacencyPolicy, error) {
+	for policy, name := range adjacencyNames {
+		if name == s {
+			return policy, nil
+		}
+	}
+	return ADJACENCY_ALLOWED, fmt.Errorf("%w: %s", ErrInvalidAdjacency, s)
+}
+
+// Neighbours returns the offsets of the cells another ship may not occupy
+// next to a ship cell.
+func (p AdjacencyPolicy) Neighbours() []Vector2 {
+	edges := []Vector2{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	switch p {
+	case ADJACENCY_NO_EDGES:
+		return edges
+	case ADJACENCY_NO_CONTACT:
+		return append(edges, Vector2{1, 1}, Vector2{1, -1}, Vector2{-1, 1}, Vector2{-1, -1})
+	default:
+		return nil
+	}
+}
+
+// checkAdjacency reports the first cell of ship that touches another ship
+// of the fleet in a way the policy forbids.
+func (f *Fleet) checkAdjacency(ship *Ship) error {
+	for _, position := range ship.positions {
+		for _, offset := range f.rules.Adjacency.Neighbours() {
+			neighbour := Vector2{position.X + offset.X, position.Y + offset.Y}
+			if other, exists := f.positions[neighbour]; exists && other != ship {
+				return fmt.Errorf("%w: (%d, %d) touches (%d, %d)", ErrAdjacent,
+					position.X, position.Y, neighbour.X, neighbour.Y)
+			}
+		}
+	}
+	return nil
+}
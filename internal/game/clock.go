@@ -0,0 +1,119 @@
+package game
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// TimeoutPolicy decides what happens to a player who lets an attack
+// deadline pass.
+type TimeoutPolicy int
+
+const (
+	TIMEOUT_LOSES TimeoutPolicy = iota
+	TIMEOUT_RANDOM_SHOT
+)
+
+var timeoutNames = map[TimeoutPolicy]string{
+	TIMEOUT_LOSES:       "lose",
+	TIMEOUT_RANDOM_SHOT: "random-shot",
+}
+
+func (p TimeoutPolicy) String() string {
+	if name, exists := timeoutNames[p]; exists {
+		return name
+	}
+	return fmt.Sprintf("TimeoutPolicy(%d)", int(p))
+}
+
+// ParseTimeoutPolicy reads a policy by the name String gives it.
+func ParseTimeoutPolicy(s string) (TimeoutPolicy, error) {
+	for policy, name := range timeoutNames {
+		if name == s {
+			return policy, nil
+		}
+	}
+	return TIMEOUT_LOSES, fmt.Errorf("%w: %s", ErrInvalidTimeout, s)
+}
+
+// TimeControl limits how long players may think. A zero duration disables
+// the corresponding limit.
+type TimeControl struct {
+	AttackTimeout time.Duration // per attack
+	Bank          time.Duration // chess clock total per player
+	SetupTimeout  time.Duration // to place the fleet and send READY
+	OnTimeout     TimeoutPolicy // on a missed attack deadline, running out of bank always loses
+}
+
+// clock is a player's chess clock.
+type clock struct {
+	used      time.Duration
+	running   bool
+	startedAt time.Time
+}
+
+// StartClock starts the player's clock for their next attack.
+func (g *Game) StartClock(player *Player, now time.Time) {
+	player.clock.running = true
+	player.clock.startedAt = now
+}
+
+// StopClock charges the time since StartClock to the player's bank.
+func (g *Game) StopClock(player *Player, now time.Time) {
+	if !player.clock.running {
+		return
+	}
+	player.clock.used += now.Sub(player.clock.startedAt)
+	player.clock.running = false
+}
+
+// BankRemaining returns what is left of the player's bank.
+func (g *Game) BankRemaining(player *Player) time.Duration {
+	return max(g.TimeControl.Bank-player.clock.used, 0)
+}
+
+// BankExhausted reports whether the player used up their whole bank.
+func (g *Game) BankExhausted(player *Player) bool {
+	return g.TimeControl.Bank > 0 && player.clock.used >= g.TimeControl.Bank
+}
+
+// AttackDeadline returns how long the player has for their next attack,
+// limited by both the attack timeout and their bank. The second value is
+// false when there is no limit at all.
+func (g *Game) AttackDeadline(player *Player) (time.Duration, bool) {
+	deadline := g.TimeControl.AttackTimeout
+	if g.TimeControl.Bank > 0 {
+		bank := g.BankRemaining(player)
+		if deadline == 0 || bank < deadline {
+			deadline = bank
+		}
+		return deadline, true
+	}
+	return deadline, deadline > 0
+}
+
+// RandomTarget picks a cell of the opponent's board the attacker did not
+// shoot at yet.
+func (g *Game) RandomTarget(attacker *Player) Vector2 {
+	return g.RandomTargets(attacker, 1)[0]
+}
+
+// RandomTargets picks n different cells of the opponent's board the attacker
+// did not shoot at yet, or as many as are left.
+func (g *Game) RandomTargets(attacker *Player, n int) []Vector2 {
+	fleet := g.GetOtherPlayer(attacker.id).Fleet
+
+	var targets []Vector2
+	for x := 0; isValidCoordinate(x, fleet.boardSize); x++ {
+		for y := 0; isValidCoordinate(y, fleet.boardSize); y++ {
+			if position := (Vector2{x, y}); !fleet.isShot(position) {
+				targets = append(targets, position)
+			}
+		}
+	}
+	rand.Shuffle(len(targets), func(i, j int) {
+		targets[i], targets[j] = targets[j], targets[i]
+	})
+	return targets[:min(n, len(targets))]
+}
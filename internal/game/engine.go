@@ -0,0 +1,346 @@
+package game
+
+import "fmt"
+
+// Command is something a player does in a game. Apply checks it against the
+// rules and turns it into the Events it caused. Players are told apart by
+// their seat, 0 for P1 and 1 for P2.
+type Command interface {
+	seat() int
+}
+
+type Join struct {
+	Seat int
+	Id   int // what the transport knows the player by, see GetPlayer
+	Name string
+}
+
+type PlaceShip struct {
+	Seat      int
+	Placement Placement
+}
+
+// PlaceFleet replaces the whole layout, all or nothing.
+type PlaceFleet struct {
+	Seat       int
+	Placements []Placement
+}
+
+type RemoveShip struct {
+	Seat int
+	At   Vector2
+}
+
+type ResetFleet struct {
+	Seat int
+}
+
+type Ready struct {
+	Seat int
+}
+
+type Attack struct {
+	Seat   int
+	Target Vector2
+}
+
+type Salvo struct {
+	Seat    int
+	Targets []Vector2
+}
+
+type Strike struct {
+	Seat   int
+	Centre Vector2
+}
+
+type Sonar struct {
+	Seat   int
+	Centre Vector2
+}
+
+type Torpedo struct {
+	Seat      int
+	Start     Vector2
+	Direction Vector2
+}
+
+// Forfeit gives the game away, e.g. when a player quits or runs out of time.
+type Forfeit struct {
+	Seat   int
+	Reason string
+}
+
+func (c Join) seat() int       { return c.Seat }
+func (c PlaceShip) seat() int  { return c.Seat }
+func (c PlaceFleet) seat() int { return c.Seat }
+func (c RemoveShip) seat() int { return c.Seat }
+func (c ResetFleet) seat() int { return c.Seat }
+func (c Ready) seat() int      { return c.Seat }
+func (c Attack) seat() int     { return c.Seat }
+func (c Salvo) seat() int      { return c.Seat }
+func (c Strike) seat() int     { return c.Seat }
+func (c Sonar) seat() int      { return c.Seat }
+func (c Torpedo) seat() int    { return c.Seat }
+func (c Forfeit) seat() int    { return c.Seat }
+
+// Event is something that happened in a game, in the order Apply reports
+// them. Seat is the player it happened to or who made it happen.
+type Event interface {
+	event()
+}
+
+type PlayerJoined struct {
+	Seat int
+	Name string
+}
+
+type ShipPlaced struct {
+	Seat      int
+	Placement Placement
+}
+
+type FleetPlaced struct {
+	Seat       int
+	Placements []Placement
+}
+
+type ShipRemoved struct {
+	Seat     int
+	ShipType ShipType
+}
+
+type FleetReset struct {
+	Seat int
+}
+
+type PlayerReady struct {
+	Seat int
+}
+
+// GameStarted follows the second PlayerReady.
+type GameStarted struct{}
+
+type TurnStarted struct {
+	Seat int
+}
+
+// ShotFired is a single ATTACK by Seat at the opponent's fleet.
+type ShotFired struct {
+	Seat int
+	Shot Shot
+}
+
+type SalvoFired struct {
+	Seat  int
+	Shots []Shot
+}
+
+type StrikeFired struct {
+	Seat   int
+	Centre Vector2
+	Shots  []Shot
+}
+
+type SonarPinged struct {
+	Seat   int
+	Centre Vector2
+	Found  bool
+}
+
+type TorpedoFired struct {
+	Seat      int
+	Start     Vector2
+	Direction Vector2
+	Shots     []Shot
+}
+
+// GameWon ends the game. Reason is empty when the loser's fleet was sunk.
+type GameWon struct {
+	Seat   int
+	Reason string
+}
+
+func (PlayerJoined) event() {}
+func (ShipPlaced) event()   {}
+func (FleetPlaced) event()  {}
+func (ShipRemoved) event()  {}
+func (FleetReset) event()   {}
+func (PlayerReady) event()  {}
+func (GameStarted) event()  {}
+func (TurnStarted) event()  {}
+func (ShotFired) event()    {}
+func (SalvoFired) event()   {}
+func (StrikeFired) event()  {}
+func (SonarPinged) event()  {}
+func (TorpedoFired) event() {}
+func (GameWon) event()      {}
+
+// Apply runs a command against the game. It either succeeds and returns
+// what happened, or fails and leaves the game as it was.
+func (g *Game) Apply(cmd Command) ([]Event, error) {
+	seat := cmd.seat()
+	if seat != 0 && seat != 1 {
+		return nil, fmt.Errorf("%w: seat %d", ErrNoPlayer, seat)
+	}
+	if join, ok := cmd.(Join); ok {
+		return g.join(join)
+	}
+
+	player := g.players[seat]
+	if player == nil {
+		return nil, fmt.Errorf("%w: seat %d", ErrNoPlayer, seat)
+	}
+	if g.IsOver() {
+		return nil, fmt.Errorf("%w: game over", ErrWrongPhase)
+	}
+
+	switch cmd := cmd.(type) {
+	case PlaceShip, PlaceFleet, RemoveShip, ResetFleet, Ready:
+		if player.State != SETUP_FLEET {
+			return nil, fmt.Errorf("%w: fleet already placed", ErrWrongPhase)
+		}
+		return g.applySetup(player, cmd)
+	case Forfeit:
+		if g.players[1-seat] == nil {
+			return nil, fmt.Errorf("%w: seat %d", ErrNoPlayer, 1-seat)
+		}
+		return g.win(1-seat, cmd.Reason), nil
+	default:
+		if player.State != PLAYING && player.State != WAITING_FOR_ATTACK {
+			return nil, fmt.Errorf("%w: game not started", ErrWrongPhase)
+		}
+		return g.applyFire(player, cmd)
+	}
+}
+
+func (g *Game) join(cmd Join) ([]Event, error) {
+	if g.players[cmd.Seat] != nil {
+		return nil, fmt.Errorf("%w: seat %d taken", ErrWrongPhase, cmd.Seat)
+	}
+	player := g.AddPlayer(cmd.Seat, cmd.Id, cmd.Name)
+	player.State = SETUP_FLEET
+	return []Event{PlayerJoined{Seat: cmd.Seat, Name: cmd.Name}}, nil
+}
+
+func (g *Game) applySetup(player *Player, cmd Command) ([]Event, error) {
+	seat := cmd.seat()
+
+	switch cmd := cmd.(type) {
+	case PlaceShip:
+		placement := cmd.Placement
+		if err := player.AddShip(string(placement.ShipType), placement.X, placement.Y, placement.Direction); err != nil {
+			return nil, err
+		}
+		return []Event{ShipPlaced{Seat: seat, Placement: placement}}, nil
+	case PlaceFleet:
+		if err := player.PlaceFleet(cmd.Placements); err != nil {
+			return nil, err
+		}
+		return []Event{FleetPlaced{Seat: seat, Placements: cmd.Placements}}, nil
+	case RemoveShip:
+		shipType, err := player.RemoveShipAt(cmd.At.X, cmd.At.Y)
+		if err != nil {
+			return nil, err
+		}
+		return []Event{ShipRemoved{Seat: seat, ShipType: shipType}}, nil
+	case ResetFleet:
+		player.ResetFleet()
+		return []Event{FleetReset{Seat: seat}}, nil
+	default:
+		if err := player.MarkReady(); err != nil {
+			return nil, err
+		}
+		player.State = WAITING_FOR_OPPONENT
+		events := []Event{PlayerReady{Seat: seat}}
+		if !g.IsReady() {
+			return events, nil
+		}
+
+		g.players[0].State = PLAYING
+		g.players[1].State = WAITING_FOR_ATTACK
+		return append(events, GameStarted{}, TurnStarted{Seat: 0}), nil
+	}
+}
+
+// applyFire resolves an attack or ability, then checks for victory and
+// hands the turn over when the turn policy says so.
+func (g *Game) applyFire(player *Player, cmd Command) ([]Event, error) {
+	seat := cmd.seat()
+	turnCount := g.TurnCount
+
+	var event Event
+	switch cmd := cmd.(type) {
+	case Attack:
+		hit, sunkShipType, err := g.Attack(player, cmd.Target.X, cmd.Target.Y)
+		if err != nil {
+			return nil, err
+		}
+		shot := Shot{Position: cmd.Target, Hit: hit}
+		if sunkShipType != nil {
+			shot.Sunk = *sunkShipType
+		}
+		event = ShotFired{Seat: seat, Shot: shot}
+	case Salvo:
+		shots, err := g.Salvo(player, cmd.Targets)
+		if err != nil {
+			return nil, err
+		}
+		event = SalvoFired{Seat: seat, Shots: shots}
+	case Strike:
+		shots, err := g.Strike(player, cmd.Centre)
+		if err != nil {
+			return nil, err
+		}
+		event = StrikeFired{Seat: seat, Centre: cmd.Centre, Shots: shots}
+	case Sonar:
+		found, err := g.Sonar(player, cmd.Centre)
+		if err != nil {
+			return nil, err
+		}
+		event = SonarPinged{Seat: seat, Centre: cmd.Centre, Found: found}
+	case Torpedo:
+		shots, err := g.Torpedo(player, cmd.Start, cmd.Direction)
+		if err != nil {
+			return nil, err
+		}
+		event = TorpedoFired{Seat: seat, Start: cmd.Start, Direction: cmd.Direction, Shots: shots}
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrWrongPhase, cmd)
+	}
+
+	events := []Event{event}
+	if g.players[1-seat].AllShipsSunk() {
+		return append(events, g.win(seat, "")...), nil
+	}
+	if g.TurnCount != turnCount {
+		player.State = WAITING_FOR_ATTACK
+		g.players[1-seat].State = PLAYING
+		events = append(events, TurnStarted{Seat: 1 - seat})
+	}
+	return events, nil
+}
+
+func (g *Game) win(seat int, reason string) []Event {
+	g.players[seat].State = WON
+	g.players[1-seat].State = LOST
+	return []Event{GameWon{Seat: seat, Reason: reason}}
+}
+
+// IsOver tells whether somebody won the game.
+func (g *Game) IsOver() bool {
+	for _, player := range g.players {
+		if player != nil && player.State == WON {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentSeat returns the seat of the player whose turn it is.
+func (g *Game) CurrentSeat() int {
+	if g.TurnCount%2 == 0 {
+		return 1
+	}
+	return 0
+}
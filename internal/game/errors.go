@@ -0,0 +1,55 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Rule violations reported by the game. Errors returned from this package
+// wrap one of these, so callers can tell them apart with errors.Is.
+var (
+	ErrInvalidCoordinate  = errors.New("invalid coordinate")
+	ErrOutOfBounds        = errors.New("coordinate out of bounds")
+	ErrOverlap            = errors.New("position already occupied")
+	ErrInvalidShipType    = errors.New("invalid ship type")
+	ErrInvalidDirection   = errors.New("invalid direction")
+	ErrFleetIncomplete    = errors.New("fleet not complete")
+	ErrAlreadyReady       = errors.New("player already ready")
+	ErrNotYourTurn        = errors.New("not your turn")
+	ErrInvalidBoardSize   = errors.New("invalid board size")
+	ErrInvalidRuleset     = errors.New("invalid ruleset")
+	ErrTooManyShips       = errors.New("too many ships of this type")
+	ErrAdjacent           = errors.New("ships may not touch")
+	ErrInvalidAdjacency   = errors.New("invalid adjacency policy")
+	ErrNoShip             = errors.New("no ship there")
+	ErrAlreadyShot        = errors.New("cell already attacked")
+	ErrInvalidRepeat      = errors.New("invalid repeat policy")
+	ErrInvalidTurns       = errors.New("invalid turn policy")
+	ErrTurnPolicy         = errors.New("not allowed by the turn policy")
+	ErrSalvoSize          = errors.New("wrong number of shots in salvo")
+	ErrInvalidAbility     = errors.New("invalid ability")
+	ErrAbilityUnavailable = errors.New("ability not available")
+	ErrNoPlayer           = errors.New("no player in that seat")
+	ErrWrongPhase         = errors.New("not allowed in this phase")
+	ErrFleetTooLarge      = errors.New("fleet does not fit the board")
+	ErrInvalidTimeout     = errors.New("invalid timeout policy")
+)
+
+// ParseCoordinate converts a coordinate sent by a client to an int.
+func ParseCoordinate(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidCoordinate, s)
+	}
+	return n, nil
+}
+
+// ParseBoardSize converts a board size sent by a client to an int.
+func ParseBoardSize(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < MIN_BOARD_SIZE || n > MAX_BOARD_SIZE {
+		return 0, fmt.Errorf("%w: %s, must be %d to %d", ErrInvalidBoardSize, s, MIN_BOARD_SIZE, MAX_BOARD_SIZE)
+	}
+	return n, nil
+}
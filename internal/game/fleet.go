@@ -3,49 +3,108 @@ package game
 import (
 	"fmt"
 	"log"
+	"slices"
 )
 
-const (
-	FLEET_UNIT_SIZE = 5*1 + 4*1 + 3*2 + 2*3 + 1*4
-)
+// Shot is an attack received by a fleet. Sunk is set when the shot sank a ship.
+type Shot struct {
+	Position Vector2
+	Hit      bool
+	Sunk     ShipType
+}
 
 type Fleet struct {
 	ships              map[ShipType][]*Ship
 	positions          map[Vector2]*Ship
+	shots              []Shot
+	attacked           map[Vector2]bool
 	remainingShipUnits int
 	Ready              bool
 	UnitSize           int
+	rules              *Ruleset
+	boardSize          int
 }
 
-func newFleet() *Fleet {
+func newFleet(rules *Ruleset, boardSize int) *Fleet {
 	return &Fleet{
+		rules:              rules,
+		boardSize:          boardSize,
 		ships:              make(map[ShipType][]*Ship),
 		positions:          make(map[Vector2]*Ship),
-		remainingShipUnits: FLEET_UNIT_SIZE,
+		attacked:           make(map[Vector2]bool),
+		remainingShipUnits: rules.UnitSize(),
 	}
 }
 
+// addShip places a ship. It either fits and is placed as a whole, or the
+// fleet is left exactly as it was.
 func (f *Fleet) addShip(ship *Ship) error {
+	if err := f.checkPlacement(ship); err != nil {
+		return err
+	}
+
 	f.ships[ship.shipType] = append(f.ships[ship.shipType], ship)
 	for _, position := range ship.positions {
-		if _, exists := f.positions[position]; exists {
-			return fmt.Errorf("position %v already occupied", position)
-		}
 		f.positions[position] = ship
+	}
+	f.UnitSize += len(ship.positions)
+	return nil
+}
 
-		f.UnitSize++
+// checkPlacement tells why ship cannot be added to the fleet, if it can't.
+func (f *Fleet) checkPlacement(ship *Ship) error {
+	class, _ := f.rules.Class(ship.shipType)
+	if placed := len(f.ships[ship.shipType]); placed >= class.Count {
+		return fmt.Errorf("%w: %d of %d %s already placed", ErrTooManyShips, placed, class.Count, ship.shipType)
+	}
+	// an overlap is the more useful error, report it before any contact
+	if position, exists := f.overlap(ship); exists {
+		return fmt.Errorf("%w: (%d, %d)", ErrOverlap, position.X, position.Y)
+	}
+	return f.checkAdjacency(ship)
+}
+
+// removeShip takes a ship off the board again.
+func (f *Fleet) removeShip(ship *Ship) {
+	f.ships[ship.shipType] = slices.DeleteFunc(f.ships[ship.shipType], func(s *Ship) bool {
+		return s == ship
+	})
+	for _, position := range ship.positions {
+		delete(f.positions, position)
+	}
+	f.UnitSize -= len(ship.positions)
+}
+
+// checkComplete tells whether exactly the ships of the ruleset are placed.
+func (f *Fleet) checkComplete() error {
+	for _, class := range f.rules.Ships {
+		if placed := len(f.ships[class.Type]); placed != class.Count {
+			return fmt.Errorf("%w: %d of %d %s placed", ErrFleetIncomplete, placed, class.Count, class.Type)
+		}
 	}
 	return nil
 }
 
+// overlap returns the first cell of ship that is already taken.
+func (f *Fleet) overlap(ship *Ship) (Vector2, bool) {
+	for _, position := range ship.positions {
+		if _, exists := f.positions[position]; exists {
+			return position, true
+		}
+	}
+	return Vector2{}, false
+}
+
 func (f *Fleet) getShipAtPosition(position Vector2) (*Ship, bool) {
 	ship, exists := f.positions[position]
 	return ship, exists
 }
 
 func (f *Fleet) receiveAttack(position Vector2) (bool, *ShipType) {
+	f.attacked[position] = true
 	ship, exists := f.getShipAtPosition(position)
 	if !exists {
+		f.shots = append(f.shots, Shot{Position: position})
 		return false, nil
 	}
 
@@ -55,12 +114,69 @@ func (f *Fleet) receiveAttack(position Vector2) (bool, *ShipType) {
 	log.Printf("[fleet] remaining ship units: %d", f.remainingShipUnits)
 
 	if ship.isSunk() {
+		f.shots = append(f.shots, Shot{Position: position, Hit: true, Sunk: ship.shipType})
 		return true, &ship.shipType
 	} else {
+		f.shots = append(f.shots, Shot{Position: position, Hit: true})
 		return true, nil
 	}
 }
 
+// fire resolves a shot that already passed the checks of the repeat policy.
+// A repeat shot is a wasted miss and hits nothing a second time, but it is
+// kept in the shots like any other.
+func (f *Fleet) fire(position Vector2) Shot {
+	if f.isShot(position) {
+		shot := Shot{Position: position}
+		f.shots = append(f.shots, shot)
+		return shot
+	}
+	hit, sunkShipType := f.receiveAttack(position)
+	shot := Shot{Position: position, Hit: hit}
+	if sunkShipType != nil {
+		shot.Sunk = *sunkShipType
+	}
+	return shot
+}
+
+func (f *Fleet) isShot(position Vector2) bool {
+	return f.attacked[position]
+}
+
+// unshotCells counts the cells of the board that were not attacked yet.
+func (f *Fleet) unshotCells() int {
+	return f.boardSize*f.boardSize - len(f.attacked)
+}
+
+// Shots returns the attacks received by the fleet, oldest first.
+func (f *Fleet) Shots() []Shot {
+	return append([]Shot(nil), f.shots...)
+}
+
+// Placements returns where every ship of the fleet was placed.
+func (f *Fleet) Placements() []Placement {
+	var placements []Placement
+	for _, class := range f.rules.Ships {
+		for _, ship := range f.ships[class.Type] {
+			placements = append(placements, ship.placement)
+		}
+	}
+	return placements
+}
+
+// SurvivingShips counts the ships that are not sunk yet.
+func (f *Fleet) SurvivingShips() int {
+	surviving := 0
+	for _, ships := range f.ships {
+		for _, ship := range ships {
+			if !ship.isSunk() {
+				surviving++
+			}
+		}
+	}
+	return surviving
+}
+
 func (f *Fleet) allShipsSunk() bool {
 	return f.remainingShipUnits == 0
 }
@@ -1,5 +1,7 @@
 package game
 
+import "fmt"
+
 type GameStatus int
 
 const (
@@ -9,31 +11,49 @@ const (
 )
 
 const (
-	TURN_MAX_ATTACKS = 3
+	TURN_MAX_ATTACKS = 3 // shots per turn in the standard ruleset
 )
 
 type Game struct {
-	State     GameStatus
-	players   [2]*Player
-	TurnCount int
+	State       GameStatus
+	players     [2]*Player
+	TurnCount   int
+	TimeControl TimeControl
+	BoardSize   int      // set before players are added
+	Rules       *Ruleset // set before players are added
 }
 
 func NewGame() *Game {
 	return &Game{
 		players:   [2]*Player{},
 		TurnCount: 1,
+		BoardSize: DEFAULT_BOARD_SIZE,
+		Rules:     Rulesets[DEFAULT_RULESET],
 	}
 }
 
 func (g *Game) GetPlayer(connectionId int) *Player {
-	if connectionId%2 == 0 {
-		return g.players[1]
+	for _, player := range g.players {
+		if player != nil && player.id == connectionId {
+			return player
+		}
 	}
-	return g.players[0]
+	return nil
+}
+
+// PlayerAt returns the player in seat 0 (P1) or 1 (P2), nil if nobody said
+// HELLO from there yet.
+func (g *Game) PlayerAt(seat int) *Player {
+	return g.players[seat]
 }
 
 func (g *Game) GetOtherPlayer(connectionId int) *Player {
-	return g.GetPlayer(connectionId ^ 1)
+	for _, player := range g.players {
+		if player != nil && player.id != connectionId {
+			return player
+		}
+	}
+	return nil
 }
 
 func (g *Game) IsReady() bool {
@@ -43,15 +63,51 @@ func (g *Game) IsReady() bool {
 	return g.players[0].Fleet.Ready && g.players[1].Fleet.Ready
 }
 
-func (g *Game) AddPlayer(connectionId int, playerName string) *Player {
-	player := newPlayer(connectionId, playerName)
+// AddPlayer seats a player in the game. Seat 0 is P1 and seat 1 is P2.
+func (g *Game) AddPlayer(seat int, connectionId int, playerName string) *Player {
+	player := newPlayer(connectionId, seat+1, playerName, g.Rules, g.BoardSize)
+	g.players[seat] = player
 
-	if player.getNumber() == 1 {
-		g.players[0] = player
+	return player
+}
+
+// Attack fires one shot from attacker at the opponent's fleet and advances
+// the turn once the turn policy of the ruleset says it is over.
+func (g *Game) Attack(attacker *Player, x int, y int) (bool, *ShipType, error) {
+	if g.Rules.Turns.Salvo() {
+		return false, nil, fmt.Errorf("%w: %s, fire with SALVO", ErrTurnPolicy, g.Rules.Turns)
+	}
+	if !g.IsPlayersTurn(attacker) {
+		return false, nil, ErrNotYourTurn
+	}
+	opponent := g.GetOtherPlayer(attacker.id)
+
+	hit, sunkShipType, err := opponent.ReceiveAttack(x, y)
+	if err != nil {
+		return false, nil, err
+	}
+
+	g.advanceTurn(attacker, Shot{Position: Vector2{x, y}, Hit: hit})
+	return hit, sunkShipType, nil
+}
+
+// advanceTurn counts shot against the attacker's turn and passes the turn
+// once the turn policy says it is over.
+func (g *Game) advanceTurn(attacker *Player, shot Shot) {
+	// attacker.TurnCount counts the shots of the current turn
+	if g.Rules.Turns.TurnOver(g.Rules, attacker.TurnCount, shot) {
+		attacker.TurnCount = 1
+		g.TurnCount++
 	} else {
-		g.players[1] = player
+		attacker.TurnCount++
 	}
+}
 
+// ReattachPlayer hands the player in seat over to a new connection, e.g.
+// after a client reconnected.
+func (g *Game) ReattachPlayer(seat int, connectionId int) *Player {
+	player := g.players[seat]
+	player.id = connectionId
 	return player
 }
 
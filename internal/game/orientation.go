@@ -0,0 +1,123 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Orientation is how a ship is turned on the board: rotated clockwise by
+// 0, 90, 180 or 270 degrees (y grows downwards), after an optional mirror
+// image across its length. "H" and "V" are kept as names for 0 and 90.
+type Orientation struct {
+	Rotation int
+	Mirrored bool
+}
+
+// ParseOrientation reads an orientation as sent in SHIP: H, V, 0, 90, 180
+// or 270, followed by M for the mirror image.
+func ParseOrientation(s string) (Orientation, error) {
+	var o Orientation
+	rotation, mirrored := strings.CutSuffix(s, "M")
+	o.Mirrored = mirrored
+
+	switch rotation {
+	case "H":
+		o.Rotation = 0
+	case "V":
+		o.Rotation = 90
+	default:
+		n, err := strconv.Atoi(rotation)
+		if err != nil || n%90 != 0 || n < 0 || n >= 360 {
+			return o, fmt.Errorf("%w: %s", ErrInvalidDirection, s)
+		}
+		o.Rotation = n
+	}
+	return o, nil
+}
+
+func (o Orientation) String() string {
+	if o.Mirrored {
+		return fmt.Sprintf("%dM", o.Rotation)
+	}
+	return strconv.Itoa(o.Rotation)
+}
+
+// apply turns an offset of a rotation 0 shape.
+func (o Orientation) apply(v Vector2) Vector2 {
+	if o.Mirrored {
+		v = Vector2{v.X, -v.Y}
+	}
+	for range o.Rotation / 90 {
+		v = Vector2{-v.Y, v.X}
+	}
+	return v
+}
+
+// cells returns the offsets of the class turned to o.
+func (c ShipClass) cells(o Orientation) []Vector2 {
+	cells := make([]Vector2, len(c.Shape))
+	for i, offset := range c.Shape {
+		cells[i] = o.apply(offset)
+	}
+	return cells
+}
+
+// Cells returns the offsets from the cell sent in SHIP that the class covers
+// when placed facing direction.
+func (c ShipClass) Cells(direction string) ([]Vector2, error) {
+	orientation, err := ParseOrientation(direction)
+	if err != nil {
+		return nil, err
+	}
+	if orientation.Mirrored && !c.Mirror {
+		return nil, fmt.Errorf("%w: %s cannot be mirrored", ErrInvalidDirection, c.Type)
+	}
+	return c.cells(orientation), nil
+}
+
+// Orientations lists the orientations the class may be placed in, leaving
+// out those that only repeat the footprint of an earlier one.
+func (c ShipClass) Orientations() []string {
+	var orientations []string
+	var footprints [][]Vector2
+
+	for _, mirrored := range []bool{false, true} {
+		if mirrored && !c.Mirror {
+			break
+		}
+		for rotation := 0; rotation < 360; rotation += 90 {
+			o := Orientation{Rotation: rotation, Mirrored: mirrored}
+			footprint := normalize(c.cells(o))
+			if slices.ContainsFunc(footprints, func(f []Vector2) bool { return slices.Equal(f, footprint) }) {
+				continue
+			}
+			footprints = append(footprints, footprint)
+			orientations = append(orientations, o.String())
+		}
+	}
+	return orientations
+}
+
+// normalize moves cells to the top left corner and sorts them, so equal
+// footprints compare equal.
+func normalize(cells []Vector2) []Vector2 {
+	minX, minY := cells[0].X, cells[0].Y
+	for _, cell := range cells {
+		minX = min(minX, cell.X)
+		minY = min(minY, cell.Y)
+	}
+
+	normalized := make([]Vector2, len(cells))
+	for i, cell := range cells {
+		normalized[i] = Vector2{cell.X - minX, cell.Y - minY}
+	}
+	slices.SortFunc(normalized, func(a, b Vector2) int {
+		if a.Y != b.Y {
+			return a.Y - b.Y
+		}
+		return a.X - b.X
+	})
+	return normalized
+}
@@ -0,0 +1,58 @@
+package game
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+const (
+	placementAttempts = 100  // for each ship of a layout
+	layoutAttempts    = 1000 // for whole layouts, before giving up on the fleet
+)
+
+// RandomPlacements lays out a full, legal fleet of the ruleset at random on
+// a board of the given size. It fails with ErrFleetTooLarge when no layout
+// was found in layoutAttempts tries, as the fleet barely fits the board if
+// it fits at all.
+func RandomPlacements(rng *rand.Rand, rules *Ruleset, boardSize int) ([]Placement, error) {
+	for range layoutAttempts {
+		if placements, ok := tryRandomPlacements(rng, rules, boardSize); ok {
+			return placements, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s on %dx%d with adjacency %s", ErrFleetTooLarge, rules.Name, boardSize, boardSize, rules.Adjacency)
+}
+
+// tryRandomPlacements places the ships in ruleset order and gives up when one
+// of them does not fit after placementAttempts tries.
+func tryRandomPlacements(rng *rand.Rand, rules *Ruleset, boardSize int) ([]Placement, bool) {
+	fleet := newFleet(rules, boardSize)
+	var placements []Placement
+
+	for _, class := range rules.Ships {
+		shipType := class.Type
+		orientations := class.Orientations()
+		for range class.Count {
+			placed := false
+			for range placementAttempts {
+				placement := Placement{
+					ShipType:  shipType,
+					X:         rng.IntN(boardSize),
+					Y:         rng.IntN(boardSize),
+					Direction: orientations[rng.IntN(len(orientations))],
+				}
+				ship, err := newShip(rules, shipType, placement.X, placement.Y, placement.Direction, boardSize)
+				if err != nil || fleet.addShip(ship) != nil {
+					continue
+				}
+				placements = append(placements, placement)
+				placed = true
+				break
+			}
+			if !placed {
+				return nil, false
+			}
+		}
+	}
+	return placements, true
+}
@@ -2,63 +2,94 @@ package game
 
 import (
 	"fmt"
-	"strconv"
 )
 
 type PlayerStatus int
 
 const (
-	WAITING_FOR_HELLO PlayerStatus = iota
+	IN_LOBBY PlayerStatus = iota
+	WAITING_FOR_HELLO
 	SETUP_FLEET
+	WAITING_FOR_OPPONENT
 	PLAYING
 	WAITING_FOR_ATTACK
 	WON
 	LOST
+	SPECTATING
 )
 
+func (s PlayerStatus) String() string {
+	switch s {
+	case IN_LOBBY:
+		return "IN_LOBBY"
+	case WAITING_FOR_HELLO:
+		return "WAITING_FOR_HELLO"
+	case SETUP_FLEET:
+		return "SETUP_FLEET"
+	case WAITING_FOR_OPPONENT:
+		return "WAITING_FOR_OPPONENT"
+	case PLAYING:
+		return "PLAYING"
+	case WAITING_FOR_ATTACK:
+		return "WAITING_FOR_ATTACK"
+	case WON:
+		return "WON"
+	case LOST:
+		return "LOST"
+	case SPECTATING:
+		return "SPECTATING"
+	default:
+		return fmt.Sprintf("PlayerStatus(%d)", int(s))
+	}
+}
+
 type Player struct {
 	id        int
+	number    int
 	name      string
 	Fleet     *Fleet
 	TurnCount int
 	State     PlayerStatus
+	clock     clock
 }
 
-func newPlayer(id int, name string) *Player {
-	fleet := newFleet()
+func newPlayer(id int, number int, name string, rules *Ruleset, boardSize int) *Player {
+	fleet := newFleet(rules, boardSize)
 
 	return &Player{
 		id:        id,
+		number:    number,
 		name:      name,
 		Fleet:     fleet,
 		TurnCount: 1,
 	}
 }
 
-func (p *Player) ReceiveAttack(x string, y string) (bool, *ShipType) {
-	xInt, err := strconv.Atoi(x)
-	if err != nil {
-		panic(err)
+func (p *Player) ReceiveAttack(x int, y int) (bool, *ShipType, error) {
+	position := Vector2{x, y}
+	if err := p.checkTarget(position); err != nil {
+		return false, nil, err
 	}
-	yInt, err := strconv.Atoi(y)
-	if err != nil {
-		panic(err)
+	shot := p.Fleet.fire(position)
+	if shot.Sunk != "" {
+		return true, &shot.Sunk, nil
 	}
-	position := Vector2{xInt, yInt}
-	return p.Fleet.receiveAttack(position)
+	return shot.Hit, nil, nil
 }
 
-func (p *Player) AddShip(shipType string, x string, y string, s string) error {
-	xInt, err := strconv.Atoi(x)
-	if err != nil {
-		panic(err)
+// checkTarget tells why position cannot be shot at, if it can't.
+func (p *Player) checkTarget(position Vector2) error {
+	if !isValidCoordinate(position.X, p.Fleet.boardSize) || !isValidCoordinate(position.Y, p.Fleet.boardSize) {
+		return fmt.Errorf("%w: (%d, %d)", ErrOutOfBounds, position.X, position.Y)
 	}
-	yInt, err := strconv.Atoi(y)
-	if err != nil {
-		panic(err)
+	if p.Fleet.isShot(position) && p.Fleet.rules.Repeat == REPEAT_REJECT {
+		return fmt.Errorf("%w: (%d, %d)", ErrAlreadyShot, position.X, position.Y)
 	}
+	return nil
+}
 
-	ship, err := newShip(ShipType(shipType), xInt, yInt, s)
+func (p *Player) AddShip(shipType string, x int, y int, s string) error {
+	ship, err := newShip(p.Fleet.rules, ShipType(shipType), x, y, s, p.Fleet.boardSize)
 	if err != nil {
 		return err
 	}
@@ -66,16 +97,75 @@ func (p *Player) AddShip(shipType string, x string, y string, s string) error {
 	return err
 }
 
+// CheckShip tells why a ship could not be placed, the way AddShip would, but
+// leaves the fleet alone. Clients use it to preview a placement.
+func (p *Player) CheckShip(placement Placement) error {
+	ship, err := newShip(p.Fleet.rules, placement.ShipType, placement.X, placement.Y, placement.Direction, p.Fleet.boardSize)
+	if err != nil {
+		return err
+	}
+	return p.Fleet.checkPlacement(ship)
+}
+
+// PlaceFleet replaces the whole layout at once. The placements must make up
+// a complete fleet; if any of them is rejected the old layout is kept.
+func (p *Player) PlaceFleet(placements []Placement) error {
+	fleet := newFleet(p.Fleet.rules, p.Fleet.boardSize)
+	for i, placement := range placements {
+		ship, err := newShip(fleet.rules, placement.ShipType, placement.X, placement.Y, placement.Direction, fleet.boardSize)
+		if err == nil {
+			err = fleet.addShip(ship)
+		}
+		if err != nil {
+			return fmt.Errorf("ship %d: %w", i+1, err)
+		}
+	}
+	if err := fleet.checkComplete(); err != nil {
+		return err
+	}
+
+	p.Fleet = fleet
+	return nil
+}
+
+// RemoveShipAt takes the ship covering x, y off the board.
+func (p *Player) RemoveShipAt(x int, y int) (ShipType, error) {
+	ship, exists := p.Fleet.getShipAtPosition(Vector2{x, y})
+	if !exists {
+		return "", fmt.Errorf("%w: (%d, %d)", ErrNoShip, x, y)
+	}
+	p.Fleet.removeShip(ship)
+	return ship.shipType, nil
+}
+
+// ResetFleet clears the board to start placing again.
+func (p *Player) ResetFleet() {
+	p.Fleet = newFleet(p.Fleet.rules, p.Fleet.boardSize)
+}
+
+// MarkReady locks the fleet in once exactly the ships of the ruleset have
+// been placed.
+func (p *Player) MarkReady() error {
+	if p.Fleet.Ready {
+		return ErrAlreadyReady
+	}
+	if err := p.Fleet.checkComplete(); err != nil {
+		return err
+	}
+	p.Fleet.Ready = true
+	return nil
+}
+
+func (p *Player) GetName() string {
+	return p.name
+}
+
 func (p *Player) GetPlayerCode() string {
 	return "P" + fmt.Sprintf("%d", p.getNumber())
 }
 
 func (p *Player) getNumber() int {
-	number := 1
-	if p.id%2 == 0 {
-		number = 2
-	}
-	return number
+	return p.number
 }
 
 func (p *Player) AllShipsSunk() bool {
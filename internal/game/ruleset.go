@@ -0,0 +1,325 @@
+package game
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ShipClass is a kind of ship a ruleset allows. Its Shape is a polyomino
+// given once, at rotation 0 and relative to the cell sent in SHIP; the other
+// orientations are derived from it.
+type ShipClass struct {
+	Type   ShipType
+	Count  int // how many of them make up a fleet
+	Shape  []Vector2
+	Mirror bool // whether the mirror image may be placed as well
+	// Ability is the special weapon every ship of the class carries, if any.
+	Ability Ability
+}
+
+// Length is the number of cells the ship covers.
+func (c ShipClass) Length() int {
+	return len(c.Shape)
+}
+
+// Ruleset declares what a fleet is made of and how many shots make a turn.
+type Ruleset struct {
+	Name           string
+	Ships          []ShipClass // in the order fleets are listed
+	AttacksPerTurn int
+	Adjacency      AdjacencyPolicy
+	Repeat         RepeatPolicy
+	Turns          TurnPolicy
+}
+
+const (
+	DEFAULT_RULESET = "standard"
+)
+
+//go:embed rulesets.json
+var builtinRulesets []byte
+
+// Presets are rulesets by name.
+type Presets map[string]*Ruleset
+
+// Rulesets are the presets a game can be created with: the built-in ones
+// and whatever LoadRulesets added.
+var Rulesets = Presets{}
+
+func init() {
+	if err := LoadRulesets(bytes.NewReader(builtinRulesets)); err != nil {
+		panic(err)
+	}
+}
+
+// rulesetFile is the JSON layout of a ruleset definition file. Shapes are
+// lists of [x, y] offsets.
+type rulesetFile struct {
+	Rulesets []struct {
+		Name           string `json:"name"`
+		AttacksPerTurn int    `json:"attacksPerTurn"`
+		Adjacency      string `json:"adjacency"` // defaults to "allowed"
+		Repeat         string `json:"repeat"`    // defaults to "reject"
+		Turns          string `json:"turns"`     // defaults to "fixed"
+		Ships          []struct {
+			Type    string   `json:"type"`
+			Count   int      `json:"count"`
+			Shape   [][2]int `json:"shape"`
+			Mirror  bool     `json:"mirror"`
+			Ability string   `json:"ability"`
+		} `json:"ships"`
+	} `json:"rulesets"`
+}
+
+// LoadRulesets reads ruleset definitions and adds them to Rulesets,
+// replacing presets of the same name.
+func LoadRulesets(r io.Reader) error {
+	loaded, err := ReadRulesets(r)
+	if err != nil {
+		return err
+	}
+	for _, rules := range loaded {
+		Rulesets[rules.Name] = rules
+	}
+	return nil
+}
+
+// ReadRulesets reads and checks ruleset definitions without adding them to
+// Rulesets, e.g. to give a server presets of its own.
+func ReadRulesets(r io.Reader) ([]*Ruleset, error) {
+	var file rulesetFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRuleset, err)
+	}
+
+	loaded := make([]*Ruleset, 0, len(file.Rulesets))
+	for _, definition := range file.Rulesets {
+		rules := &Ruleset{Name: definition.Name, AttacksPerTurn: definition.AttacksPerTurn, Turns: TURNS_FIXED}
+		if definition.Adjacency != "" {
+			adjacency, err := ParseAdjacencyPolicy(definition.Adjacency)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRuleset, definition.Name, err)
+			}
+			rules.Adjacency = adjacency
+		}
+		if definition.Repeat != "" {
+			repeat, err := ParseRepeatPolicy(definition.Repeat)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRuleset, definition.Name, err)
+			}
+			rules.Repeat = repeat
+		}
+		if definition.Turns != "" {
+			turns, err := ParseTurnPolicy(definition.Turns)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRuleset, definition.Name, err)
+			}
+			rules.Turns = turns
+		}
+		for _, ship := range definition.Ships {
+			ability, err := ParseAbility(ship.Ability)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRuleset, definition.Name, err)
+			}
+			class := ShipClass{Type: ShipType(ship.Type), Count: ship.Count, Mirror: ship.Mirror, Ability: ability}
+			for _, cell := range ship.Shape {
+				class.Shape = append(class.Shape, Vector2{cell[0], cell[1]})
+			}
+			rules.Ships = append(rules.Ships, class)
+		}
+		if err := rules.validate(); err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, rules)
+	}
+	return loaded, nil
+}
+
+// LoadRulesetsFile is LoadRulesets for a file on disk.
+func LoadRulesetsFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return LoadRulesets(f)
+}
+
+func (r *Ruleset) validate() error {
+	if r.Name == "" || len(r.Ships) == 0 || r.AttacksPerTurn < 1 {
+		return fmt.Errorf("%w: %q needs a name, ships and attacksPerTurn", ErrInvalidRuleset, r.Name)
+	}
+	for i, class := range r.Ships {
+		if class.Type == "" || class.Count < 1 {
+			return fmt.Errorf("%w: %s: every ship needs a type and a count", ErrInvalidRuleset, r.Name)
+		}
+		if len(class.Type) > MAX_SHIP_TYPE_LENGTH || strings.ContainsFunc(string(class.Type), unicode.IsSpace) {
+			return fmt.Errorf("%w: %s: %q must be a single word of at most %d characters", ErrInvalidRuleset, r.Name, class.Type, MAX_SHIP_TYPE_LENGTH)
+		}
+		if slices.ContainsFunc(r.Ships[:i], func(c ShipClass) bool { return c.Type == class.Type }) {
+			return fmt.Errorf("%w: %s: %s listed twice", ErrInvalidRuleset, r.Name, class.Type)
+		}
+		if !isPolyomino(class.Shape) {
+			return fmt.Errorf("%w: %s: %s must be a connected shape without repeated cells", ErrInvalidRuleset, r.Name, class.Type)
+		}
+	}
+	if err := r.CheckFits(MIN_BOARD_SIZE); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRuleset, err)
+	}
+	return nil
+}
+
+// CheckFits fails with ErrFleetTooLarge when the fleet cannot fit a board of
+// the given size under the adjacency policy.
+func (r *Ruleset) CheckFits(boardSize int) error {
+	if !r.fitsBoard(boardSize) {
+		return fmt.Errorf("%w: %s on %dx%d with adjacency %s", ErrFleetTooLarge, r.Name, boardSize, boardSize, r.Adjacency)
+	}
+	return nil
+}
+
+// fitsBoard tells whether the fleet has the room it needs on a board of the
+// given size, counting cells. Ships that may not touch at all are counted
+// grown by a cell to the right and below, on a board a cell larger: grown
+// that way, no two of them may cover the same cell.
+func (r *Ruleset) fitsBoard(size int) bool {
+	needed, room := 0, size*size
+	if r.Adjacency == ADJACENCY_NO_CONTACT {
+		room = (size + 1) * (size + 1)
+	}
+	for _, class := range r.Ships {
+		cells := len(class.Shape)
+		if r.Adjacency == ADJACENCY_NO_CONTACT {
+			grown := make(map[Vector2]bool)
+			for _, cell := range class.Shape {
+				for _, d := range []Vector2{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+					grown[Vector2{cell.X + d.X, cell.Y + d.Y}] = true
+				}
+			}
+			cells = len(grown)
+		}
+		needed += cells * class.Count
+	}
+	return needed <= room
+}
+
+// isPolyomino tells whether cells are distinct and connected side to side.
+func isPolyomino(cells []Vector2) bool {
+	if len(cells) == 0 {
+		return false
+	}
+	set := make(map[Vector2]bool, len(cells))
+	for _, cell := range cells {
+		if set[cell] {
+			return false
+		}
+		set[cell] = true
+	}
+
+	seen := map[Vector2]bool{cells[0]: true}
+	stack := []Vector2{cells[0]}
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, d := range []Vector2{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			next := Vector2{cell.X + d.X, cell.Y + d.Y}
+			if set[next] && !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return len(seen) == len(cells)
+}
+
+// LookupRuleset finds a preset of Rulesets by name.
+func LookupRuleset(name string) (*Ruleset, error) {
+	return Rulesets.Lookup(name)
+}
+
+// Lookup finds a preset by name.
+func (p Presets) Lookup(name string) (*Ruleset, error) {
+	rules, exists := p[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRuleset, name)
+	}
+	return rules, nil
+}
+
+// With returns a copy of the presets with rulesets added, replacing presets
+// of the same name.
+func (p Presets) With(rulesets ...*Ruleset) Presets {
+	presets := maps.Clone(p)
+	for _, rules := range rulesets {
+		presets[rules.Name] = rules
+	}
+	return presets
+}
+
+// WithAdjacency returns a copy of the ruleset with another adjacency policy.
+func (r *Ruleset) WithAdjacency(policy AdjacencyPolicy) *Ruleset {
+	rules := *r
+	rules.Adjacency = policy
+	return &rules
+}
+
+// WithRepeat returns a copy of the ruleset with another repeat shot policy.
+func (r *Ruleset) WithRepeat(policy RepeatPolicy) *Ruleset {
+	rules := *r
+	rules.Repeat = policy
+	return &rules
+}
+
+// WithTurns returns a copy of the ruleset with another turn policy.
+func (r *Ruleset) WithTurns(policy TurnPolicy) *Ruleset {
+	rules := *r
+	rules.Turns = policy
+	return &rules
+}
+
+// Class returns the ship class of a type, if the ruleset has it.
+func (r *Ruleset) Class(shipType ShipType) (ShipClass, bool) {
+	for _, class := range r.Ships {
+		if class.Type == shipType {
+			return class, true
+		}
+	}
+	return ShipClass{}, false
+}
+
+// ShipCount is the number of ships a full fleet is made of.
+func (r *Ruleset) ShipCount() int {
+	count := 0
+	for _, class := range r.Ships {
+		count += class.Count
+	}
+	return count
+}
+
+// UnitSize is the number of cells a full fleet covers.
+func (r *Ruleset) UnitSize() int {
+	size := 0
+	for _, class := range r.Ships {
+		size += class.Count * class.Length()
+	}
+	return size
+}
+
+// ShipCells returns the cells a ship would cover if placed at x, y facing
+// direction, or an error if it does not fit on the board.
+func (r *Ruleset) ShipCells(shipType ShipType, x int, y int, direction string, boardSize int) ([]Vector2, error) {
+	ship, err := newShip(r, shipType, x, y, direction, boardSize)
+	if err != nil {
+		return nil, err
+	}
+	return ship.positions, nil
+}
@@ -4,6 +4,17 @@ import "fmt"
 
 type ShipType string
 
+// Boards are square. Labels run from A to Z, hence the maximum size.
+const (
+	DEFAULT_BOARD_SIZE = 10
+	MIN_BOARD_SIZE     = 8
+	MAX_BOARD_SIZE     = 26
+)
+
+// MAX_SHIP_TYPE_LENGTH bounds ship type names, so that a FLEET for the
+// largest board still fits a protocol line.
+const MAX_SHIP_TYPE_LENGTH = 16
+
 const (
 	Carrier    ShipType = "CARRIER"
 	Cruiser    ShipType = "CRUISER"
@@ -16,124 +27,46 @@ type Vector2 struct {
 	X, Y int
 }
 
-// Ship
-type Ship struct {
-	shipType  ShipType
-	length    int
-	positions []Vector2
-	remaining int
-}
-
-type ShipSpec struct {
-	length  int
-	offsets []Vector2
-}
-
-var shipSpecsH = map[ShipType]ShipSpec{
-	Carrier: {
-		length: 5,
-		offsets: []Vector2{
-			{0, 0},
-			{1, 0},
-			{2, 0},
-			{2, 1},
-			{2, -1},
-		},
-	},
-	Cruiser: {
-		length: 4,
-		offsets: []Vector2{
-			{0, 0},
-			{1, 0},
-			{2, 0},
-			{3, 0},
-		},
-	},
-	Battleship: {
-		length: 3,
-		offsets: []Vector2{
-			{0, 0},
-			{1, 0},
-			{2, 0},
-		},
-	},
-	Destroyer: {
-		length: 2,
-		offsets: []Vector2{
-			{0, 0},
-			{1, 0},
-		},
-	},
-	Submarine: {
-		length: 1,
-		offsets: []Vector2{
-			{0, 0},
-		},
-	},
+// Placement is where a ship was put on the board, as sent in a SHIP command.
+type Placement struct {
+	ShipType  ShipType
+	X, Y      int
+	Direction string
 }
 
-var shipSpecsV = map[ShipType]ShipSpec{
-	Carrier: {
-		length: 5,
-		offsets: []Vector2{
-			{0, 0},
-			{0, 1},
-			{0, 2},
-			{1, 2},
-			{-1, 2},
-		},
-	},
-	Cruiser: {
-		length: 4,
-		offsets: []Vector2{
-			{0, 0},
-			{0, 1},
-			{0, 2},
-			{0, 3},
-		},
-	},
-	Battleship: {
-		length: 3,
-		offsets: []Vector2{
-			{0, 0},
-			{0, 1},
-			{0, 2},
-		},
-	},
-	Destroyer: {
-		length: 2,
-		offsets: []Vector2{
-			{0, 0},
-			{0, 1},
-		},
-	},
-	Submarine: {
-		length: 1,
-		offsets: []Vector2{
-			{0, 0},
-		},
-	},
+// Ship
+type Ship struct {
+	shipType    ShipType
+	placement   Placement
+	length      int
+	positions   []Vector2
+	remaining   int
+	ability     Ability
+	abilityUsed bool
 }
 
-func newShip(shipType ShipType, x int, y int, direction string) (*Ship, error) {
-	var shipSpec ShipSpec
-	switch direction {
-	case "H":
-		shipSpec = shipSpecsH[shipType]
-	case "V":
-		shipSpec = shipSpecsV[shipType]
-	default:
-		return nil, fmt.Errorf("invalid direction: %s", direction)
+func newShip(rules *Ruleset, shipType ShipType, x int, y int, direction string, boardSize int) (*Ship, error) {
+	orientation, err := ParseOrientation(direction)
+	if err != nil {
+		return nil, err
+	}
+	class, exists := rules.Class(shipType)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidShipType, shipType)
+	}
+	if orientation.Mirrored && !class.Mirror {
+		return nil, fmt.Errorf("%w: %s cannot be mirrored", ErrInvalidDirection, shipType)
 	}
 
-	length := shipSpec.length
+	offsets := class.cells(orientation)
+	length := len(offsets)
 	positions := make([]Vector2, length)
 
 	for i := range length {
-		newX := x + shipSpec.offsets[i].X
-		newY := y + shipSpec.offsets[i].Y
-		if !isValidCoordinate(newX) || !isValidCoordinate(newY) {
-			return nil, fmt.Errorf("invalid coordinates: (%d, %d)", newX, newY)
+		newX := x + offsets[i].X
+		newY := y + offsets[i].Y
+		if !isValidCoordinate(newX, boardSize) || !isValidCoordinate(newY, boardSize) {
+			return nil, fmt.Errorf("%w: (%d, %d)", ErrOutOfBounds, newX, newY)
 		}
 		positions[i] = Vector2{
 			X: newX,
@@ -143,14 +76,16 @@ func newShip(shipType ShipType, x int, y int, direction string) (*Ship, error) {
 
 	return &Ship{
 		shipType:  shipType,
+		placement: Placement{ShipType: shipType, X: x, Y: y, Direction: direction},
 		length:    length,
 		positions: positions,
 		remaining: length,
+		ability:   class.Ability,
 	}, nil
 }
 
-func isValidCoordinate(n int) bool {
-	if n < 0 || n > 9 {
+func isValidCoordinate(n int, boardSize int) bool {
+	if n < 0 || n >= boardSize {
 		return false
 	}
 	return true
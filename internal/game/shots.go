@@ -0,0 +1,82 @@
+package game
+
+import "fmt"
+
+// RepeatPolicy decides what happens to a shot at a cell that was already
+// attacked.
+type RepeatPolicy int
+
+const (
+	REPEAT_REJECT RepeatPolicy = iota // the shot is refused and may be fired again
+	REPEAT_MISS                       // the shot is used up as a miss
+)
+
+var repeatNames = map[RepeatPolicy]string{
+	REPEAT_REJECT: "reject",
+	REPEAT_MISS:   "miss",
+}
+
+func (p RepeatPolicy) String() string {
+	if name, exists := repeatNames[p]; exists {
+		return name
+	}
+	return fmt.Sprintf("RepeatPolicy(%d)", int(p))
+}
+
+// ParseRepeatPolicy reads a policy by the name String gives it.
+func ParseRepeatPolicy(s string) (RepeatPolicy, error) {
+	for policy, name := range repeatNames {
+		if name == s {
+			return policy, nil
+		}
+	}
+	return REPEAT_REJECT, fmt.Errorf("%w: %s", ErrInvalidRepeat, s)
+}
+
+// Cell is what an attacker knows about a cell of the opponent's board.
+type Cell int
+
+const (
+	CELL_UNKNOWN Cell = iota
+	CELL_MISS
+	CELL_HIT
+	CELL_SUNK
+)
+
+// String returns the character a cell is drawn with in a BOARD reply.
+func (c Cell) String() string {
+	switch c {
+	case CELL_MISS:
+		return "o"
+	case CELL_HIT:
+		return "x"
+	case CELL_SUNK:
+		return "#"
+	default:
+		return "."
+	}
+}
+
+// ShotGrid returns what the attacks on the fleet revealed, indexed [y][x].
+// Every cell of a sunk ship is reported as sunk.
+func (f *Fleet) ShotGrid() [][]Cell {
+	grid := make([][]Cell, f.boardSize)
+	for y := range grid {
+		grid[y] = make([]Cell, f.boardSize)
+	}
+	for _, shot := range f.shots {
+		// a repeat shot reveals nothing new
+		if grid[shot.Position.Y][shot.Position.X] != CELL_UNKNOWN {
+			continue
+		}
+		cell := CELL_MISS
+		if shot.Hit {
+			cell = CELL_HIT
+			if ship, _ := f.getShipAtPosition(shot.Position); ship.isSunk() {
+				cell = CELL_SUNK
+			}
+		}
+		grid[shot.Position.Y][shot.Position.X] = cell
+	}
+	return grid
+}
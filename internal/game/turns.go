@@ -0,0 +1,120 @@
+package game
+
+import "fmt"
+
+// TurnPolicy decides how many shots make up a turn and when the turn passes
+// to the opponent. String names the policy in rulesets and game options.
+type TurnPolicy interface {
+	String() string
+	// Shots returns how many shots a player with surviving ships gets in a
+	// turn, or 0 when the turn lasts until TurnOver says so.
+	Shots(rules *Ruleset, surviving int) int
+	// Salvo tells whether the shots of a turn are fired all at once.
+	Salvo() bool
+	// TurnOver tells whether the turn ends with shot, the fired-th shot of
+	// the turn. It is not asked about salvos.
+	TurnOver(rules *Ruleset, fired int, shot Shot) bool
+}
+
+var (
+	TURNS_FIXED      TurnPolicy = fixedTurns{}     // AttacksPerTurn shots
+	TURNS_SINGLE     TurnPolicy = singleTurns{}    // one shot
+	TURNS_UNTIL_MISS TurnPolicy = untilMissTurns{} // shots until one misses
+	TURNS_SALVO      TurnPolicy = salvoTurns{}     // one shot per surviving ship, fired at once
+)
+
+// TurnPolicies are the policies a ruleset or game can be played with, by
+// name. More can be added before the server starts.
+var TurnPolicies = map[string]TurnPolicy{}
+
+func init() {
+	for _, policy := range []TurnPolicy{TURNS_FIXED, TURNS_SINGLE, TURNS_UNTIL_MISS, TURNS_SALVO} {
+		TurnPolicies[policy.String()] = policy
+	}
+}
+
+// ParseTurnPolicy looks a policy up by name.
+func ParseTurnPolicy(s string) (TurnPolicy, error) {
+	if policy, exists := TurnPolicies[s]; exists {
+		return policy, nil
+	}
+	return TURNS_FIXED, fmt.Errorf("%w: %s", ErrInvalidTurns, s)
+}
+
+type fixedTurns struct{}
+
+func (fixedTurns) String() string                  { return "fixed" }
+func (fixedTurns) Shots(rules *Ruleset, _ int) int { return rules.AttacksPerTurn }
+func (fixedTurns) Salvo() bool                     { return false }
+func (fixedTurns) TurnOver(rules *Ruleset, fired int, _ Shot) bool {
+	return fired >= rules.AttacksPerTurn
+}
+
+type singleTurns struct{}
+
+func (singleTurns) String() string                    { return "single" }
+func (singleTurns) Shots(*Ruleset, int) int           { return 1 }
+func (singleTurns) Salvo() bool                       { return false }
+func (singleTurns) TurnOver(*Ruleset, int, Shot) bool { return true }
+
+type untilMissTurns struct{}
+
+func (untilMissTurns) String() string          { return "until-miss" }
+func (untilMissTurns) Shots(*Ruleset, int) int { return 0 }
+func (untilMissTurns) Salvo() bool             { return false }
+func (untilMissTurns) TurnOver(_ *Ruleset, _ int, shot Shot) bool {
+	return !shot.Hit
+}
+
+type salvoTurns struct{}
+
+func (salvoTurns) String() string                      { return "salvo" }
+func (salvoTurns) Shots(_ *Ruleset, surviving int) int { return surviving }
+func (salvoTurns) Salvo() bool                         { return true }
+func (salvoTurns) TurnOver(*Ruleset, int, Shot) bool   { return true }
+
+// ShotsPerTurn returns how many shots the player may fire this turn, 0 when
+// the turn policy sets no number. When repeats are rejected it is never more
+// than the cells of the opponent's board left to shoot at.
+func (g *Game) ShotsPerTurn(player *Player) int {
+	shots := g.Rules.Turns.Shots(g.Rules, player.Fleet.SurvivingShips())
+	if opponent := g.GetOtherPlayer(player.id); opponent != nil && g.Rules.Repeat == REPEAT_REJECT {
+		shots = min(shots, opponent.Fleet.unshotCells())
+	}
+	return shots
+}
+
+// Salvo fires all of the attacker's shots for the turn at once and hands the
+// turn to the opponent. Every target is checked first, so a salvo is either
+// fired as a whole or not at all. The shots are returned in the order they
+// were given.
+func (g *Game) Salvo(attacker *Player, targets []Vector2) ([]Shot, error) {
+	if !g.Rules.Turns.Salvo() {
+		return nil, fmt.Errorf("%w: %s, fire with ATTACK", ErrTurnPolicy, g.Rules.Turns)
+	}
+	if !g.IsPlayersTurn(attacker) {
+		return nil, ErrNotYourTurn
+	}
+	if expected := g.ShotsPerTurn(attacker); len(targets) != expected {
+		return nil, fmt.Errorf("%w: %d shots, expected %d", ErrSalvoSize, len(targets), expected)
+	}
+	opponent := g.GetOtherPlayer(attacker.id)
+
+	aimed := make(map[Vector2]bool)
+	for _, target := range targets {
+		if err := opponent.checkTarget(target); err != nil {
+			return nil, err
+		}
+		if aimed[target] && g.Rules.Repeat == REPEAT_REJECT {
+			return nil, fmt.Errorf("%w: (%d, %d)", ErrAlreadyShot, target.X, target.Y)
+		}
+		aimed[target] = true
+	}
+
+	shots := make([]Shot, len(targets))
+	for i, target := range targets {
+		shots[i] = opponent.Fleet.fire(target)
+	}
+	g.TurnCount++
+	return shots, nil
+}
@@ -0,0 +1,177 @@
+// Package matchlog records what happens in a game so it can be replayed.
+//
+// A match log is a file of JSON lines, appended to as the game goes and
+// never rewritten. The first line sets the game up, every following line is
+// either a command the game accepted or one of the events it caused, in the
+// order they happened:
+//
+//	{"seq":0,"time":"...","turn":1,"kind":"setup","type":"Setup","data":{"BoardSize":10,"Rules":"standard",...}}
+//	{"seq":1,"time":"...","turn":1,"player":"P1","kind":"command","type":"Join","data":{"Seat":0,"Id":1,"Name":"alice"}}
+//	{"seq":2,"time":"...","turn":1,"player":"P1","kind":"event","type":"PlayerJoined","data":{"Seat":0,"Name":"alice"}}
+//
+// Type names the game.Command or game.Event in data, turn is the game's
+// TurnCount when the command was applied. Rejected commands are not logged:
+// they did not change the game.
+package matchlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+var (
+	ErrInvalidLog = errors.New("invalid match log")
+	ErrDiverged   = errors.New("replay diverged from the log")
+)
+
+const (
+	KIND_SETUP   = "setup"
+	KIND_COMMAND = "command"
+	KIND_EVENT   = "event"
+)
+
+// Entry is one line of a match log.
+type Entry struct {
+	Seq    int             `json:"seq"`
+	Time   time.Time       `json:"time"`
+	Turn   int             `json:"turn"`
+	Player string          `json:"player,omitempty"` // P1 or P2, who issued the command or whom the event concerns
+	Kind   string          `json:"kind"`
+	Type   string          `json:"type"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Setup is what a game was created with, everything Apply depends on besides
+// the commands. Rulesets loaded from a file must be loaded again to replay
+// games played with them.
+type Setup struct {
+	BoardSize int
+	Rules     string
+	Adjacency string
+	Repeat    string
+	Turns     string
+}
+
+// SetupOf reads the setup of a game that was not played yet.
+func SetupOf(g *game.Game) Setup {
+	return Setup{
+		BoardSize: g.BoardSize,
+		Rules:     g.Rules.Name,
+		Adjacency: g.Rules.Adjacency.String(),
+		Repeat:    g.Rules.Repeat.String(),
+		Turns:     g.Rules.Turns.String(),
+	}
+}
+
+// NewGame creates a game the way it was set up.
+func (s Setup) NewGame() (*game.Game, error) {
+	rules, err := game.LookupRuleset(s.Rules)
+	if err != nil {
+		return nil, err
+	}
+	adjacency, err := game.ParseAdjacencyPolicy(s.Adjacency)
+	if err != nil {
+		return nil, err
+	}
+	repeat, err := game.ParseRepeatPolicy(s.Repeat)
+	if err != nil {
+		return nil, err
+	}
+	turns, err := game.ParseTurnPolicy(s.Turns)
+	if err != nil {
+		return nil, err
+	}
+
+	g := game.NewGame()
+	g.BoardSize = s.BoardSize
+	g.Rules = rules.WithAdjacency(adjacency).WithRepeat(repeat).WithTurns(turns)
+	return g, nil
+}
+
+// Writer appends to a match log. It is not safe for concurrent use, the
+// game it records is expected to be locked anyway.
+type Writer struct {
+	w   io.WriteCloser
+	enc *json.Encoder
+	seq int
+	now func() time.Time
+}
+
+// Create starts a match log in dir for game, which must not have been played
+// yet. The file is named after the time and the game id.
+func Create(dir string, gameId int, g *game.Game) (*Writer, error) {
+	now := time.Now()
+	name := fmt.Sprintf("match-%s-%d.jsonl", now.Format("20060102-150405"), gameId)
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(file, SetupOf(g))
+}
+
+// NewWriter starts a match log on w by writing the setup line.
+func NewWriter(w io.WriteCloser, setup Setup) (*Writer, error) {
+	writer := &Writer{
+		w:   w,
+		enc: json.NewEncoder(w),
+		now: time.Now,
+	}
+	if err := writer.write(1, KIND_SETUP, setup); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// Record logs a command Apply accepted during turn, followed by the events it
+// returned.
+func (w *Writer) Record(turn int, cmd game.Command, events []game.Event) error {
+	if err := w.write(turn, KIND_COMMAND, cmd); err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := w.write(turn, KIND_EVENT, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Writer) Close() error {
+	return w.w.Close()
+}
+
+func (w *Writer) write(turn int, kind string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	entry := Entry{
+		Seq:    w.seq,
+		Time:   w.now(),
+		Turn:   turn,
+		Player: playerCode(value),
+		Kind:   kind,
+		Type:   reflect.TypeOf(value).Name(),
+		Data:   data,
+	}
+	w.seq++
+	return w.enc.Encode(entry)
+}
+
+// playerCode names the seat of a command or event, empty for the ones that
+// concern nobody in particular.
+func playerCode(value any) string {
+	seat := reflect.ValueOf(value).FieldByName("Seat")
+	if !seat.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("P%d", seat.Int()+1)
+}
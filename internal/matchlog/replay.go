@@ -0,0 +1,169 @@
+package matchlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// types are the commands and events a log may hold, by the name it gives
+// them.
+var types = map[string]reflect.Type{}
+
+func init() {
+	for _, value := range []any{
+		game.Join{}, game.PlaceShip{}, game.PlaceFleet{}, game.RemoveShip{}, game.ResetFleet{}, game.Ready{},
+		game.Attack{}, game.Salvo{}, game.Strike{}, game.Sonar{}, game.Torpedo{}, game.Forfeit{},
+		game.PlayerJoined{}, game.ShipPlaced{}, game.FleetPlaced{}, game.ShipRemoved{}, game.FleetReset{},
+		game.PlayerReady{}, game.GameStarted{}, game.TurnStarted{}, game.ShotFired{}, game.SalvoFired{},
+		game.StrikeFired{}, game.SonarPinged{}, game.TorpedoFired{}, game.GameWon{},
+	} {
+		types[reflect.TypeOf(value).Name()] = reflect.TypeOf(value)
+	}
+}
+
+// Step is a command of the log together with the events it caused.
+type Step struct {
+	Time    time.Time
+	Turn    int
+	Player  string
+	Command game.Command
+	Events  []game.Event
+}
+
+// Replay is a match log read back.
+type Replay struct {
+	Setup Setup
+	Steps []Step
+}
+
+// Open reads the match log at path.
+func Open(path string) (*Replay, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Read reads a match log. A log cut short, e.g. by a crash, is read up to its
+// last complete line.
+func Read(r io.Reader) (*Replay, error) {
+	replay := &Replay{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	var torn error // a line that could not be read, fine if it is the last one
+	for line := 1; scanner.Scan(); line++ {
+		if torn != nil {
+			return nil, torn
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			torn = fmt.Errorf("%w: line %d: %v", ErrInvalidLog, line, err)
+			continue
+		}
+		if err := replay.add(entry); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidLog, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if replay.Setup.Rules == "" {
+		return nil, fmt.Errorf("%w: no setup", ErrInvalidLog)
+	}
+	return replay, nil
+}
+
+func (r *Replay) add(entry Entry) error {
+	if entry.Kind == KIND_SETUP {
+		if r.Setup.Rules != "" {
+			return fmt.Errorf("second setup")
+		}
+		return json.Unmarshal(entry.Data, &r.Setup)
+	}
+	if r.Setup.Rules == "" {
+		return fmt.Errorf("%s before the setup", entry.Kind)
+	}
+
+	valueType, exists := types[entry.Type]
+	if !exists {
+		return fmt.Errorf("unknown type %s", entry.Type)
+	}
+	value := reflect.New(valueType)
+	if err := json.Unmarshal(entry.Data, value.Interface()); err != nil {
+		return err
+	}
+
+	switch entry.Kind {
+	case KIND_COMMAND:
+		cmd, ok := value.Elem().Interface().(game.Command)
+		if !ok {
+			return fmt.Errorf("%s is not a command", entry.Type)
+		}
+		r.Steps = append(r.Steps, Step{Time: entry.Time, Turn: entry.Turn, Player: entry.Player, Command: cmd})
+	case KIND_EVENT:
+		event, ok := value.Elem().Interface().(game.Event)
+		if !ok {
+			return fmt.Errorf("%s is not an event", entry.Type)
+		}
+		if len(r.Steps) == 0 {
+			return fmt.Errorf("event before any command")
+		}
+		step := &r.Steps[len(r.Steps)-1]
+		step.Events = append(step.Events, event)
+	default:
+		return fmt.Errorf("unknown kind %s", entry.Kind)
+	}
+	return nil
+}
+
+// GameAt rebuilds the game as it was after the first n steps, 0 being the
+// game as it was set up. Every step is applied again and must cause the
+// events that were logged for it, so a log that does not match the rules
+// it claims to follow is told apart from a genuine game.
+func (r *Replay) GameAt(n int) (*game.Game, error) {
+	if n < 0 || n > len(r.Steps) {
+		return nil, fmt.Errorf("step %d of %d", n, len(r.Steps))
+	}
+	g, err := r.Setup.NewGame()
+	if err != nil {
+		return nil, err
+	}
+	for i, step := range r.Steps[:n] {
+		events, err := g.Apply(step.Command)
+		if err != nil {
+			return nil, fmt.Errorf("%w: step %d: %v", ErrDiverged, i+1, err)
+		}
+		if !sameEvents(events, step.Events) {
+			return nil, fmt.Errorf("%w: step %d: got %v, logged %v", ErrDiverged, i+1, events, step.Events)
+		}
+	}
+	return g, nil
+}
+
+// StepAtTurn returns how many steps lead up to the start of turn, i.e. the
+// index of its first step.
+func (r *Replay) StepAtTurn(turn int) int {
+	for i, step := range r.Steps {
+		if step.Turn >= turn {
+			return i
+		}
+	}
+	return len(r.Steps)
+}
+
+// sameEvents compares events by their JSON form, the way they were logged.
+func sameEvents(events []game.Event, logged []game.Event) bool {
+	a, errA := json.Marshal(events)
+	b, errB := json.Marshal(logged)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
@@ -0,0 +1,88 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+const (
+	// MaxLineLength is the longest message, without its newline, a peer may
+	// send. It fits the longest legal one: a FLEET with a ship on every cell
+	// of the largest board, each as " <type> <x> <y> <orientation>". A SALVO
+	// never has more shots than there are ships.
+	MaxLineLength = len("FLEET") + game.MAX_BOARD_SIZE*game.MAX_BOARD_SIZE*
+		(len(" ")+game.MAX_SHIP_TYPE_LENGTH+len(" 25 25 270M"))
+)
+
+var ErrLineTooLong = errors.New("line too long")
+
+// Conn frames the battleship protocol on top of a net.Conn: every message is a
+// single line terminated by '\n'. Reads must come from a single goroutine,
+// writes are safe for concurrent use.
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex
+}
+
+func NewConn(conn net.Conn) *Conn {
+	return &Conn{
+		conn:   conn,
+		reader: bufio.NewReaderSize(conn, MaxLineLength+1),
+	}
+}
+
+// ReadLine returns the next message without its line terminator. A line
+// longer than MaxLineLength is discarded and ErrLineTooLong is returned, the
+// connection can still be read afterwards. A final line the peer did not
+// terminate is returned as well, io.EOF comes with the next read.
+func (c *Conn) ReadLine() (string, error) {
+	line, err := c.reader.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		for errors.Is(err, bufio.ErrBufferFull) {
+			_, err = c.reader.ReadSlice('\n')
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		return "", ErrLineTooLong
+	}
+	if err != nil && (len(line) == 0 || !errors.Is(err, io.EOF)) {
+		return "", err
+	}
+
+	line = bytes.TrimRight(line, "\r\n")
+	return string(line), nil
+}
+
+// WriteLine sends a single message, appending the line terminator.
+func (c *Conn) WriteLine(line string) error {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.ContainsRune(line, '\n') {
+		return errors.New("message must be a single line")
+	}
+	if len(line) > MaxLineLength {
+		return ErrLineTooLong
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := c.conn.Write([]byte(line + "\n"))
+	return err
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// handleStrikeCommand shoots a 3x3 area and reports every cell it shot
+// between STRIKE and END.
+func (gm *GameManager) handleStrikeCommand(connectionId int, cmd Command) error {
+	strike := cmd.(StrikeCommand)
+
+	gameState := gm.getGameState(connectionId)
+	seat := gameState.getSeat(connectionId)
+	return gm.fire(gameState, seat, game.Strike{Seat: seat, Centre: game.Vector2{X: strike.X, Y: strike.Y}})
+}
+
+// handleSonarCommand tells everybody whether a 3x3 area holds a ship.
+func (gm *GameManager) handleSonarCommand(connectionId int, cmd Command) error {
+	sonar := cmd.(SonarCommand)
+
+	gameState := gm.getGameState(connectionId)
+	seat := gameState.getSeat(connectionId)
+	return gm.fire(gameState, seat, game.Sonar{Seat: seat, Centre: game.Vector2{X: sonar.X, Y: sonar.Y}})
+}
+
+// handleTorpedoCommand runs a torpedo along a row or column and reports every
+// cell it shot between TORPEDO and END.
+func (gm *GameManager) handleTorpedoCommand(connectionId int, cmd Command) error {
+	torpedo := cmd.(TorpedoCommand)
+
+	start, direction := game.Vector2{Y: torpedo.Index}, game.Vector2{X: 1}
+	if torpedo.Column {
+		start, direction = game.Vector2{X: torpedo.Index}, game.Vector2{Y: 1}
+	}
+	gameState := gm.getGameState(connectionId)
+	seat := gameState.getSeat(connectionId)
+	return gm.fire(gameState, seat, game.Torpedo{Seat: seat, Start: start, Direction: direction})
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/pmouraguedes/battleship/internal/ai"
+	"github.com/pmouraguedes/battleship/internal/protocol"
+)
+
+// startAIGame opens a private game against a bot. The bot is a virtual
+// connection: it talks to the server through an in-memory pipe and is
+// handled exactly like a remote player.
+func (gm *GameManager) startAIGame(connectionId int, level ai.Level, options GameOptions) *GameState {
+	serverSide, botSide := net.Pipe()
+	botId := gm.newConnectionId()
+	conn := protocol.NewConn(serverSide)
+
+	gm.mu.Lock()
+	gameState := gm.newGameState(connectionId, options)
+	gameState.mu.Lock() // still unknown to anybody else, as in enqueue
+	gameState.connections[1] = botId
+	gm.games[connectionId] = gameState
+	gm.games[botId] = gameState
+	gm.conns[botId] = conn
+	gm.mu.Unlock()
+
+	log.Printf("[server %d] playing against AI %d (%s)", connectionId, botId, level)
+	go gm.handle(conn, botId)
+	go func() {
+		bot := ai.NewBot(fmt.Sprintf("AI_%s", level), level, gm.presets())
+		if err := bot.Play(botSide); err != nil {
+			log.Printf("[ai %d] %v", botId, err)
+		}
+	}()
+	return gameState
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// startSetupClock gives the player in seat SetupTimeout to place their fleet.
+// Must be called with the game locked.
+func (gm *GameManager) startSetupClock(gameState *GameState, seat int) {
+	timeout := gameState.game.TimeControl.SetupTimeout
+	if timeout == 0 {
+		return
+	}
+	gameState.setupTimers[seat] = time.AfterFunc(timeout, func() {
+		gm.setupTimeout(gameState, seat)
+	})
+}
+
+func (gm *GameManager) stopSetupClock(gameState *GameState, seat int) {
+	if timer := gameState.setupTimers[seat]; timer != nil {
+		timer.Stop()
+		gameState.setupTimers[seat] = nil
+	}
+}
+
+func (gm *GameManager) setupTimeout(gameState *GameState, seat int) {
+	gameState.mu.Lock()
+	defer gameState.mu.Unlock()
+
+	// ready in time, or the game is already gone
+	player := gameState.game.PlayerAt(seat)
+	if player == nil || player.State != game.SETUP_FLEET || gameState.setupTimers[seat] == nil {
+		return
+	}
+
+	log.Printf("[server] player %s did not place their fleet in time", player.GetPlayerCode())
+	gm.forfeit(gameState, seat, "TIMEOUT")
+}
+
+// startTurnClock runs the clock of the player about to attack and arms the
+// attack deadline. Must be called with the game locked.
+func (gm *GameManager) startTurnClock(gameState *GameState, player *game.Player) {
+	thisGame := gameState.game
+	thisGame.StartClock(player, time.Now())
+
+	gameState.turnSeq++
+	if gameState.turnTimer != nil {
+		gameState.turnTimer.Stop()
+		gameState.turnTimer = nil
+	}
+
+	deadline, limited := thisGame.AttackDeadline(player)
+	if !limited {
+		return
+	}
+	turnSeq := gameState.turnSeq
+	gameState.turnTimer = time.AfterFunc(deadline, func() {
+		gm.attackTimeout(gameState, player, turnSeq)
+	})
+}
+
+// attackTimeout fires when a player let their attack deadline pass. Running
+// out of bank loses the game, otherwise the TimeoutPolicy decides.
+func (gm *GameManager) attackTimeout(gameState *GameState, player *game.Player, turnSeq int) {
+	gameState.mu.Lock()
+	defer gameState.mu.Unlock()
+
+	// the player attacked just before the timer fired
+	if turnSeq != gameState.turnSeq || player.State != game.PLAYING {
+		return
+	}
+
+	thisGame := gameState.game
+	thisGame.StopClock(player, time.Now())
+	if thisGame.BankExhausted(player) || thisGame.TimeControl.OnTimeout == game.TIMEOUT_LOSES {
+		log.Printf("[server] player %s ran out of time", player.GetPlayerCode())
+		gm.forfeit(gameState, gameState.playerSeat(player), "TIMEOUT")
+		return
+	}
+
+	seat := gameState.playerSeat(player)
+	var err error
+	if thisGame.Rules.Turns.Salvo() {
+		targets := thisGame.RandomTargets(player, thisGame.ShotsPerTurn(player))
+		log.Printf("[server] player %s ran out of time, firing a random salvo", player.GetPlayerCode())
+		err = gm.fire(gameState, seat, game.Salvo{Seat: seat, Targets: targets})
+	} else {
+		target := thisGame.RandomTarget(player)
+		log.Printf("[server] player %s ran out of time, shooting at (%d, %d)", player.GetPlayerCode(), target.X, target.Y)
+		err = gm.fire(gameState, seat, game.Attack{Seat: seat, Target: target})
+	}
+	// no timer is left running to try again
+	if err != nil {
+		log.Printf("[server] could not shoot for %s: %v", player.GetPlayerCode(), err)
+		gm.forfeit(gameState, seat, "TIMEOUT")
+	}
+}
+
+// stopClocks disarms every timer of a game that is over.
+// Must be called with the game locked.
+func (gm *GameManager) stopClocks(gameState *GameState) {
+	gm.stopSetupClock(gameState, 0)
+	gm.stopSetupClock(gameState, 1)
+
+	gameState.turnSeq++
+	if gameState.turnTimer != nil {
+		gameState.turnTimer.Stop()
+		gameState.turnTimer = nil
+	}
+}
+
+// turnMessage announces whose turn it is, along with their remaining time
+// when time controls are on.
+func turnMessage(thisGame *game.Game, player *game.Player) string {
+	msg := fmt.Sprintf("TURN %s", player.GetPlayerCode())
+	if thisGame.Rules.Turns.Salvo() {
+		msg += fmt.Sprintf(" shots=%d", thisGame.ShotsPerTurn(player))
+	}
+	if timeout := thisGame.TimeControl.AttackTimeout; timeout > 0 {
+		msg += fmt.Sprintf(" attack=%d", timeout.Milliseconds())
+	}
+	if thisGame.TimeControl.Bank > 0 {
+		msg += fmt.Sprintf(" bank=%d", thisGame.BankRemaining(player).Milliseconds())
+	}
+	return msg
+}
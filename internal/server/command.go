@@ -0,0 +1,433 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pmouraguedes/battleship/internal/ai"
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// Command is a parsed client message.
+type Command interface {
+	Verb() string
+}
+
+type HelloCommand struct {
+	Name    string
+	AI      ai.Level // empty unless playing against the server
+	Options GameOptions
+}
+
+type ListCommand struct{}
+
+type CreateCommand struct {
+	Room    string
+	Options GameOptions
+}
+
+type JoinCommand struct {
+	Room string
+}
+
+type QueueCommand struct {
+	Options GameOptions
+}
+
+// GameOptions are the rules a new game is created with, sent as key=value
+// arguments. The zero value means the defaults.
+type GameOptions struct {
+	BoardSize int
+	Rules     string // name of a preset of Config.Rulesets
+	Adjacency string // overrides the adjacency policy of the ruleset
+	Repeat    string // overrides the repeat shot policy of the ruleset
+	Turns     string // overrides the turn policy of the ruleset
+}
+
+type ShipCommand struct {
+	ShipType  game.ShipType
+	X, Y      int
+	Direction string
+}
+
+// FleetCommand places a whole fleet at once, one ShipCommand per ship.
+type FleetCommand struct {
+	Ships []ShipCommand
+}
+
+type UnshipCommand struct {
+	X, Y int
+}
+
+type ResetCommand struct{}
+
+type ReadyCommand struct{}
+
+type AttackCommand struct {
+	X, Y int
+}
+
+// SalvoCommand fires every shot of a turn at once.
+type SalvoCommand struct {
+	Targets []game.Vector2
+}
+
+// StrikeCommand and SonarCommand aim at the 3x3 area around a cell.
+type StrikeCommand struct {
+	X, Y int
+}
+
+type SonarCommand struct {
+	X, Y int
+}
+
+// TorpedoCommand sends a torpedo along a row or a column, from its low end.
+type TorpedoCommand struct {
+	Column bool
+	Index  int
+}
+
+type BoardCommand struct{}
+
+type QuitCommand struct{}
+
+type ResumeCommand struct {
+	Token string
+}
+
+type GamesCommand struct{}
+
+type WatchCommand struct {
+	GameId int
+}
+
+func (HelloCommand) Verb() string   { return "HELLO" }
+func (ListCommand) Verb() string    { return "LIST" }
+func (CreateCommand) Verb() string  { return "CREATE" }
+func (JoinCommand) Verb() string    { return "JOIN" }
+func (QueueCommand) Verb() string   { return "QUEUE" }
+func (ShipCommand) Verb() string    { return "SHIP" }
+func (FleetCommand) Verb() string   { return "FLEET" }
+func (UnshipCommand) Verb() string  { return "UNSHIP" }
+func (ResetCommand) Verb() string   { return "RESET" }
+func (ReadyCommand) Verb() string   { return "READY" }
+func (AttackCommand) Verb() string  { return "ATTACK" }
+func (SalvoCommand) Verb() string   { return "SALVO" }
+func (StrikeCommand) Verb() string  { return "STRIKE" }
+func (SonarCommand) Verb() string   { return "SONAR" }
+func (TorpedoCommand) Verb() string { return "TORPEDO" }
+func (BoardCommand) Verb() string   { return "BOARD" }
+func (QuitCommand) Verb() string    { return "QUIT" }
+func (ResumeCommand) Verb() string  { return "RESUME" }
+func (GamesCommand) Verb() string   { return "GAMES" }
+func (WatchCommand) Verb() string   { return "WATCH" }
+
+// commandParser builds a Command from the arguments following the verb.
+type commandParser func(args []string) (Command, error)
+
+var commandParsers = map[string]commandParser{
+	"HELLO":   parseHelloCommand,
+	"LIST":    noArgs(ListCommand{}),
+	"CREATE":  parseCreateCommand,
+	"JOIN":    parseJoinCommand,
+	"QUEUE":   parseQueueCommand,
+	"SHIP":    parseShipCommand,
+	"FLEET":   parseFleetCommand,
+	"UNSHIP":  parseUnshipCommand,
+	"RESET":   noArgs(ResetCommand{}),
+	"READY":   noArgs(ReadyCommand{}),
+	"ATTACK":  parseAttackCommand,
+	"SALVO":   parseSalvoCommand,
+	"STRIKE":  parseStrikeCommand,
+	"SONAR":   parseSonarCommand,
+	"TORPEDO": parseTorpedoCommand,
+	"BOARD":   noArgs(BoardCommand{}),
+	"QUIT":    noArgs(QuitCommand{}),
+	"RESUME":  parseResumeCommand,
+	"GAMES":   noArgs(GamesCommand{}),
+	"WATCH":   parseWatchCommand,
+}
+
+// parseCommand turns a protocol line into a typed Command. It only checks
+// the syntax, whether the command makes sense right now is up to dispatch.
+func parseCommand(line string) (Command, error) {
+	parts := strings.Fields(line)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%w: empty command", errInvalidCommand)
+	}
+
+	parser, exists := commandParsers[parts[0]]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", errUnknownCommand, parts[0])
+	}
+	return parser(parts[1:])
+}
+
+func noArgs(cmd Command) commandParser {
+	return func(args []string) (Command, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("%w: %s takes no arguments", errInvalidCommand, cmd.Verb())
+		}
+		return cmd, nil
+	}
+}
+
+func parseHelloCommand(args []string) (Command, error) {
+	if len(args) == 0 || strings.Contains(args[0], "=") {
+		return nil, fmt.Errorf("%w: HELLO <name> [VS_AI <level>] [<option>=<value>...]", errInvalidCommand)
+	}
+	if !isValidName(args[0]) {
+		return nil, fmt.Errorf("%w: %s", errInvalidName, args[0])
+	}
+	hello := HelloCommand{Name: args[0]}
+	args = args[1:]
+
+	if len(args) > 0 && !strings.Contains(args[0], "=") {
+		if len(args) < 2 || args[0] != "VS_AI" {
+			return nil, fmt.Errorf("%w: HELLO <name> [VS_AI <level>] [<option>=<value>...]", errInvalidCommand)
+		}
+		level, err := ai.ParseLevel(args[1])
+		if err != nil {
+			return nil, err
+		}
+		hello.AI = level
+		args = args[2:]
+	}
+
+	options, err := parseOptions(args)
+	if err != nil {
+		return nil, err
+	}
+	hello.Options = options
+	return hello, nil
+}
+
+func parseCreateCommand(args []string) (Command, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%w: CREATE <room> [<option>=<value>...]", errInvalidCommand)
+	}
+	if !isValidName(args[0]) {
+		return nil, fmt.Errorf("%w: %s", errInvalidName, args[0])
+	}
+	options, err := parseOptions(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return CreateCommand{Room: args[0], Options: options}, nil
+}
+
+func parseQueueCommand(args []string) (Command, error) {
+	options, err := parseOptions(args)
+	if err != nil {
+		return nil, err
+	}
+	return QueueCommand{Options: options}, nil
+}
+
+// parseOptions reads the key=value game options that may follow CREATE,
+// QUEUE and HELLO.
+func parseOptions(args []string) (GameOptions, error) {
+	var options GameOptions
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			return options, fmt.Errorf("%w: expected <option>=<value>, got %s", errInvalidCommand, arg)
+		}
+
+		switch key {
+		case "size":
+			size, err := game.ParseBoardSize(value)
+			if err != nil {
+				return options, err
+			}
+			options.BoardSize = size
+		case "rules":
+			// looked up when the game is created, in the server's presets
+			options.Rules = value
+		case "adjacency":
+			if _, err := game.ParseAdjacencyPolicy(value); err != nil {
+				return options, err
+			}
+			options.Adjacency = value
+		case "repeat":
+			if _, err := game.ParseRepeatPolicy(value); err != nil {
+				return options, err
+			}
+			options.Repeat = value
+		case "turns":
+			if _, err := game.ParseTurnPolicy(value); err != nil {
+				return options, err
+			}
+			options.Turns = value
+		default:
+			return options, fmt.Errorf("%w: unknown option %s", errInvalidCommand, key)
+		}
+	}
+	return options, nil
+}
+
+// withDefaults fills in the options that were not given.
+func (o GameOptions) withDefaults() GameOptions {
+	if o.BoardSize == 0 {
+		o.BoardSize = game.DEFAULT_BOARD_SIZE
+	}
+	if o.Rules == "" {
+		o.Rules = game.DEFAULT_RULESET
+	}
+	return o
+}
+
+func parseJoinCommand(args []string) (Command, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: JOIN <room>", errInvalidCommand)
+	}
+	return JoinCommand{Room: args[0]}, nil
+}
+
+func parseShipCommand(args []string) (Command, error) {
+	if len(args) != 4 {
+		return nil, fmt.Errorf("%w: SHIP <type> <x> <y> <H|V|0|90|180|270>[M]", errInvalidCommand)
+	}
+	x, err := game.ParseCoordinate(args[1])
+	if err != nil {
+		return nil, err
+	}
+	y, err := game.ParseCoordinate(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := game.ParseOrientation(args[3]); err != nil {
+		return nil, err
+	}
+	return ShipCommand{ShipType: game.ShipType(args[0]), X: x, Y: y, Direction: args[3]}, nil
+}
+
+func parseFleetCommand(args []string) (Command, error) {
+	if len(args) == 0 || len(args)%4 != 0 {
+		return nil, fmt.Errorf("%w: FLEET <type> <x> <y> <direction> [<type> <x> <y> <direction>...]", errInvalidCommand)
+	}
+
+	var fleet FleetCommand
+	for i := 0; i < len(args); i += 4 {
+		ship, err := parseShipCommand(args[i : i+4])
+		if err != nil {
+			return nil, err
+		}
+		fleet.Ships = append(fleet.Ships, ship.(ShipCommand))
+	}
+	return fleet, nil
+}
+
+func parseUnshipCommand(args []string) (Command, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%w: UNSHIP <x> <y>", errInvalidCommand)
+	}
+	x, err := game.ParseCoordinate(args[0])
+	if err != nil {
+		return nil, err
+	}
+	y, err := game.ParseCoordinate(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return UnshipCommand{X: x, Y: y}, nil
+}
+
+func parseAttackCommand(args []string) (Command, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%w: ATTACK <x> <y>", errInvalidCommand)
+	}
+	x, err := game.ParseCoordinate(args[0])
+	if err != nil {
+		return nil, err
+	}
+	y, err := game.ParseCoordinate(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return AttackCommand{X: x, Y: y}, nil
+}
+
+func parseStrikeCommand(args []string) (Command, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%w: STRIKE <x> <y>", errInvalidCommand)
+	}
+	x, err := game.ParseCoordinate(args[0])
+	if err != nil {
+		return nil, err
+	}
+	y, err := game.ParseCoordinate(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return StrikeCommand{X: x, Y: y}, nil
+}
+
+func parseSonarCommand(args []string) (Command, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%w: SONAR <x> <y>", errInvalidCommand)
+	}
+	x, err := game.ParseCoordinate(args[0])
+	if err != nil {
+		return nil, err
+	}
+	y, err := game.ParseCoordinate(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return SonarCommand{X: x, Y: y}, nil
+}
+
+func parseTorpedoCommand(args []string) (Command, error) {
+	if len(args) != 2 || (args[0] != "ROW" && args[0] != "COL") {
+		return nil, fmt.Errorf("%w: TORPEDO <ROW|COL> <n>", errInvalidCommand)
+	}
+	index, err := game.ParseCoordinate(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return TorpedoCommand{Column: args[0] == "COL", Index: index}, nil
+}
+
+func parseSalvoCommand(args []string) (Command, error) {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return nil, fmt.Errorf("%w: SALVO <x> <y> [<x> <y>...]", errInvalidCommand)
+	}
+
+	var salvo SalvoCommand
+	for i := 0; i < len(args); i += 2 {
+		x, err := game.ParseCoordinate(args[i])
+		if err != nil {
+			return nil, err
+		}
+		y, err := game.ParseCoordinate(args[i+1])
+		if err != nil {
+			return nil, err
+		}
+		salvo.Targets = append(salvo.Targets, game.Vector2{X: x, Y: y})
+	}
+	return salvo, nil
+}
+
+func parseResumeCommand(args []string) (Command, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: RESUME <token>", errInvalidCommand)
+	}
+	return ResumeCommand{Token: args[0]}, nil
+}
+
+func parseWatchCommand(args []string) (Command, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: WATCH <gameId>", errInvalidCommand)
+	}
+	gameId, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errGameNotFound, args[0])
+	}
+	return WatchCommand{GameId: gameId}, nil
+}
+
+func isValidName(name string) bool {
+	return len(name) >= 1 && len(name) <= 20
+}
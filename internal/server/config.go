@@ -0,0 +1,34 @@
+package server
+
+import (
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// Config holds the tunable server settings.
+type Config struct {
+	// ResumeGrace is how long the seat of a player who dropped mid-game is
+	// kept for a RESUME.
+	ResumeGrace time.Duration
+
+	// TimeControl limits how long players may take, disabled by default.
+	TimeControl game.TimeControl
+
+	// Omniscient shows spectators where the ships are, for commentators.
+	Omniscient bool
+
+	// MatchLogDir is where a match log of every game is written, see
+	// package matchlog. No logs are written when empty.
+	MatchLogDir string
+
+	// Rulesets are the presets games may be created with, game.Rulesets
+	// when nil.
+	Rulesets game.Presets
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ResumeGrace: 30 * time.Second,
+	}
+}
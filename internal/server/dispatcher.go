@@ -0,0 +1,89 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// commandHandler executes a command for a connection. When the connection
+// is seated in a game the handler runs with the game locked.
+type commandHandler func(gm *GameManager, connectionId int, cmd Command) error
+
+var commandHandlers map[string]commandHandler
+
+// The table is filled in init, handlers may start new connections that end
+// up in dispatch again.
+func init() {
+	commandHandlers = map[string]commandHandler{
+		"HELLO":   (*GameManager).handleHelloCommand,
+		"LIST":    (*GameManager).handleListCommand,
+		"CREATE":  (*GameManager).handleCreateCommand,
+		"JOIN":    (*GameManager).handleJoinCommand,
+		"QUEUE":   (*GameManager).handleQueueCommand,
+		"SHIP":    (*GameManager).handleShipCommand,
+		"FLEET":   (*GameManager).handleFleetCommand,
+		"UNSHIP":  (*GameManager).handleUnshipCommand,
+		"RESET":   (*GameManager).handleResetCommand,
+		"READY":   (*GameManager).handleReadyCommand,
+		"ATTACK":  (*GameManager).handleAttackCommand,
+		"SALVO":   (*GameManager).handleSalvoCommand,
+		"STRIKE":  (*GameManager).handleStrikeCommand,
+		"SONAR":   (*GameManager).handleSonarCommand,
+		"TORPEDO": (*GameManager).handleTorpedoCommand,
+		"BOARD":   (*GameManager).handleBoardCommand,
+		"QUIT":    (*GameManager).handleQuitCommand,
+		"RESUME":  (*GameManager).handleResumeCommand,
+		"GAMES":   (*GameManager).handleGamesCommand,
+		"WATCH":   (*GameManager).handleWatchCommand,
+	}
+}
+
+// allowedCommands lists the verbs a connection may send in each state.
+var allowedCommands = map[game.PlayerStatus][]string{
+	game.IN_LOBBY:             {"HELLO", "LIST", "CREATE", "JOIN", "QUEUE", "RESUME", "GAMES", "WATCH", "QUIT"},
+	game.WAITING_FOR_HELLO:    {"HELLO", "QUIT"},
+	game.SETUP_FLEET:          {"SHIP", "FLEET", "UNSHIP", "RESET", "READY", "QUIT"},
+	game.WAITING_FOR_OPPONENT: {"QUIT"},
+	game.PLAYING:              {"ATTACK", "SALVO", "STRIKE", "SONAR", "TORPEDO", "BOARD", "QUIT"},
+	game.WAITING_FOR_ATTACK:   {"BOARD", "QUIT"},
+	game.WON:                  {"QUIT"},
+	game.LOST:                 {"QUIT"},
+	game.SPECTATING:           {"QUIT"},
+}
+
+// dispatch runs cmd if it is legal in the connection's current state.
+func (gm *GameManager) dispatch(connectionId int, cmd Command) error {
+	gameState := gm.getGameState(connectionId)
+	if gameState == nil {
+		gameState = gm.getWatchedGame(connectionId)
+	}
+	if gameState != nil {
+		gameState.mu.Lock()
+		defer gameState.mu.Unlock()
+	}
+
+	state := connectionState(gameState, connectionId)
+	log.Printf("[server %d] %s: %s", connectionId, state, cmd.Verb())
+
+	if !slices.Contains(allowedCommands[state], cmd.Verb()) {
+		return fmt.Errorf("%w: %s", errUnexpectedCommand, cmd.Verb())
+	}
+	return commandHandlers[cmd.Verb()](gm, connectionId, cmd)
+}
+
+func connectionState(gameState *GameState, connectionId int) game.PlayerStatus {
+	if gameState == nil {
+		return game.IN_LOBBY
+	}
+	player := gameState.game.GetPlayer(connectionId)
+	if player == nil && slices.Contains(gameState.spectators, connectionId) {
+		return game.SPECTATING
+	}
+	if player == nil {
+		return game.WAITING_FOR_HELLO
+	}
+	return player.State
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pmouraguedes/battleship/internal/ai"
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/protocol"
+)
+
+// Protocol errors raised by the server itself, next to the rule violations
+// defined in the game package.
+var (
+	errUnknownCommand    = errors.New("unknown command")
+	errInvalidCommand    = errors.New("invalid command")
+	errUnexpectedCommand = errors.New("unexpected command")
+	errInvalidName       = errors.New("invalid name")
+	errRoomExists        = errors.New("room already exists")
+	errRoomNotFound      = errors.New("room not found")
+	errOpponentNotFound  = errors.New("opponent not found")
+	errInvalidSession    = errors.New("invalid or expired session")
+	errSessionInUse      = errors.New("session is still connected")
+	errGameNotFound      = errors.New("game not found")
+	errGameAbandoned     = errors.New("game was abandoned")
+)
+
+// errorCodes maps every known error to the stable code sent to clients.
+// Codes must never change meaning, clients are allowed to match on them.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{errUnknownCommand, "UNKNOWN_COMMAND"},
+	{errInvalidCommand, "INVALID_COMMAND"},
+	{errUnexpectedCommand, "UNEXPECTED_COMMAND"},
+	{errInvalidName, "INVALID_NAME"},
+	{errRoomExists, "ROOM_EXISTS"},
+	{errRoomNotFound, "ROOM_NOT_FOUND"},
+	{errOpponentNotFound, "NO_OPPONENT"},
+	{errInvalidSession, "INVALID_SESSION"},
+	{errSessionInUse, "SESSION_IN_USE"},
+	{errGameNotFound, "GAME_NOT_FOUND"},
+	{errGameAbandoned, "GAME_ABANDONED"},
+	{ai.ErrInvalidLevel, "INVALID_LEVEL"},
+	{protocol.ErrLineTooLong, "LINE_TOO_LONG"},
+	{game.ErrInvalidCoordinate, "INVALID_COORDINATE"},
+	{game.ErrOutOfBounds, "OUT_OF_BOUNDS"},
+	{game.ErrOverlap, "OVERLAP"},
+	{game.ErrInvalidShipType, "INVALID_SHIP_TYPE"},
+	{game.ErrInvalidDirection, "INVALID_DIRECTION"},
+	{game.ErrFleetIncomplete, "FLEET_INCOMPLETE"},
+	{game.ErrAlreadyReady, "ALREADY_READY"},
+	{game.ErrNotYourTurn, "NOT_YOUR_TURN"},
+	{game.ErrInvalidBoardSize, "INVALID_BOARD_SIZE"},
+	{game.ErrInvalidRuleset, "INVALID_RULESET"},
+	{game.ErrTooManyShips, "TOO_MANY_SHIPS"},
+	{game.ErrAdjacent, "ADJACENT"},
+	{game.ErrInvalidAdjacency, "INVALID_ADJACENCY"},
+	{game.ErrNoShip, "NO_SHIP"},
+	{game.ErrAlreadyShot, "ALREADY_SHOT"},
+	{game.ErrInvalidRepeat, "INVALID_REPEAT"},
+	{game.ErrInvalidTurns, "INVALID_TURNS"},
+	{game.ErrTurnPolicy, "TURN_POLICY"},
+	{game.ErrSalvoSize, "SALVO_SIZE"},
+	{game.ErrAbilityUnavailable, "ABILITY_UNAVAILABLE"},
+	{game.ErrNoPlayer, "NO_PLAYER"},
+	{game.ErrWrongPhase, "WRONG_PHASE"},
+	{game.ErrFleetTooLarge, "FLEET_TOO_LARGE"},
+}
+
+// errorResponse formats err as an "ERROR <code> <text>" reply.
+func errorResponse(err error) string {
+	code := "INTERNAL"
+	for _, errorCode := range errorCodes {
+		if errors.Is(err, errorCode.err) {
+			code = errorCode.code
+			break
+		}
+	}
+	return fmt.Sprintf("ERROR %s %s", code, err)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/matchlog"
+)
+
+// apply runs cmd against the game and tells everybody concerned what
+// happened. Must be called with the game locked.
+func (gm *GameManager) apply(gameState *GameState, cmd game.Command) error {
+	turn := gameState.game.TurnCount
+	events, err := gameState.game.Apply(cmd)
+	if err != nil {
+		return err
+	}
+	gm.record(gameState, turn, cmd, events)
+	for _, event := range events {
+		gm.publish(gameState, event)
+	}
+	return nil
+}
+
+// record appends an accepted command to the game's match log, starting the
+// log on the first one. Failing to log never stops the game.
+// Must be called with the game locked.
+func (gm *GameManager) record(gameState *GameState, turn int, cmd game.Command, events []game.Event) {
+	if gm.config.MatchLogDir == "" {
+		return
+	}
+	if gameState.matchLog == nil {
+		// the first command is a Join, the game has not changed yet
+		matchLog, err := matchlog.Create(gm.config.MatchLogDir, gameState.id, gameState.game)
+		if err != nil {
+			log.Printf("[server] game %d will not be logged: %v", gameState.id, err)
+			return
+		}
+		gameState.matchLog = matchLog
+	}
+	if err := gameState.matchLog.Record(turn, cmd, events); err != nil {
+		log.Printf("[server] could not log game %d: %v", gameState.id, err)
+	}
+}
+
+// publish turns a game event into protocol messages, and starts or stops
+// the clocks it concerns. Must be called with the game locked.
+func (gm *GameManager) publish(gameState *GameState, event game.Event) {
+	thisGame := gameState.game
+
+	switch event := event.(type) {
+	case game.PlayerJoined:
+		// WELCOME carries the session token, handleHelloCommand sends it
+	case game.ShipPlaced:
+		gm.send(gameState.connections[event.Seat], fmt.Sprintf("OK SHIP %s", event.Placement.ShipType))
+	case game.FleetPlaced:
+		gm.send(gameState.connections[event.Seat], fmt.Sprintf("OK FLEET %d", len(event.Placements)))
+	case game.ShipRemoved:
+		gm.send(gameState.connections[event.Seat], fmt.Sprintf("OK UNSHIP %s", event.ShipType))
+	case game.FleetReset:
+		gm.send(gameState.connections[event.Seat], "OK RESET")
+	case game.PlayerReady:
+		log.Printf("[server] player %s is ready", thisGame.PlayerAt(event.Seat).GetPlayerCode())
+		gm.stopSetupClock(gameState, event.Seat)
+	case game.GameStarted:
+		gm.broadcast(gameState, "START P1")
+		gm.revealFleets(gameState)
+	case game.TurnStarted:
+		player := thisGame.PlayerAt(event.Seat)
+		gm.startTurnClock(gameState, player)
+		gm.send(gameState.connections[event.Seat], turnMessage(thisGame, player))
+		gm.spectate(gameState, turnMessage(thisGame, player))
+	case game.ShotFired:
+		// the shot that wins the game is announced by WIN alone
+		if !thisGame.IsOver() {
+			gm.broadcast(gameState, shotMessage(event.Shot))
+		}
+	case game.SalvoFired:
+		// every shot as a HIT or MISS first, then the ships the salvo sank,
+		// so no single hit gives away its ship
+		gm.broadcast(gameState, fmt.Sprintf("SALVO %s %d", thisGame.PlayerAt(event.Seat).GetPlayerCode(), len(event.Shots)))
+		for _, shot := range event.Shots {
+			gm.broadcast(gameState, shotMessage(game.Shot{Position: shot.Position, Hit: shot.Hit}))
+		}
+		for _, shot := range event.Shots {
+			if shot.Sunk != "" {
+				gm.broadcast(gameState, shotMessage(shot))
+			}
+		}
+		gm.broadcast(gameState, "END")
+	case game.StrikeFired:
+		header := fmt.Sprintf("STRIKE %s %d %d", thisGame.PlayerAt(event.Seat).GetPlayerCode(), event.Centre.X, event.Centre.Y)
+		gm.broadcastShots(gameState, header, event.Shots)
+	case game.SonarPinged:
+		result := "CLEAR"
+		if event.Found {
+			result = "FOUND"
+		}
+		gm.broadcast(gameState, fmt.Sprintf("SONAR %s %d %d %s", thisGame.PlayerAt(event.Seat).GetPlayerCode(), event.Centre.X, event.Centre.Y, result))
+	case game.TorpedoFired:
+		axis, index := "ROW", event.Start.Y
+		if event.Direction.Y != 0 {
+			axis, index = "COL", event.Start.X
+		}
+		header := fmt.Sprintf("TORPEDO %s %s %d", thisGame.PlayerAt(event.Seat).GetPlayerCode(), axis, index)
+		gm.broadcastShots(gameState, header, event.Shots)
+	case game.GameWon:
+		winner := thisGame.PlayerAt(event.Seat)
+		message := fmt.Sprintf("WIN %s", winner.GetPlayerCode())
+		if event.Reason != "" {
+			message += " " + event.Reason
+		}
+		log.Printf("[server] Game over: %s", message)
+		gm.broadcast(gameState, message)
+		gm.endGame(gameState)
+	}
+}
+
+// broadcastShots sends header, the shots and END to everybody in the game.
+func (gm *GameManager) broadcastShots(gameState *GameState, header string, shots []game.Shot) {
+	gm.broadcast(gameState, header)
+	for _, shot := range shots {
+		gm.broadcast(gameState, shotMessage(shot))
+	}
+	gm.broadcast(gameState, "END")
+}
@@ -1,502 +1,381 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"log"
-	"net"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/matchlog"
+	"github.com/pmouraguedes/battleship/internal/protocol"
 )
 
 type GameState struct {
+	id          int
 	game        *game.Game
-	connections [2]int
-	readyChan   chan string
+	connections [2]int // -1 while a seat is empty or its player is away
+	spectators  []int
+	tokens      [2]string
+	graceTimers [2]*time.Timer
+	setupTimers [2]*time.Timer
+	turnTimer   *time.Timer
+	turnSeq     int              // bumped whenever turnTimer is re-armed
+	matchLog    *matchlog.Writer // nil until the first command, or if not logging
+	mu          sync.Mutex       // guards everything above
 }
 
 type GameManager struct {
-	games map[int]*GameState // connectionId -> GameState
-	mu    sync.RWMutex
-	conns map[int]net.Conn // connectionId -> net.Conn
+	config   Config
+	games    map[int]*GameState // connectionId -> GameState
+	mu       sync.RWMutex
+	conns    map[int]*protocol.Conn // connectionId -> *protocol.Conn
+	sessions map[string]*GameState  // session token -> GameState
+	matches  map[int]*GameState     // game id -> GameState, until the game is over
+	watching map[int]*GameState     // spectator connectionId -> GameState
+	lobby    *Lobby
+	lastId   int // last connectionId handed out
+	lastGame int // last game id handed out
 }
 
-// func (gs *GameState) getOtherConnectionId(connectionId int) int {
-// 	if connectionId == gs.connections[0] {
-// 		return gs.connections[1]
-// 	}
-// 	return gs.connections[0]
-// }
-
-func newGameManager() *GameManager {
-	return &GameManager{
-		games: make(map[int]*GameState),
-		conns: make(map[int]net.Conn),
+func (gs *GameState) getSeat(connectionId int) int {
+	if connectionId == gs.connections[0] {
+		return 0
 	}
+	return 1
 }
 
-func (gm *GameManager) getOtherConn(connectionId int) net.Conn {
-	if connectionId%2 == 0 {
-		return gm.conns[connectionId-1]
-	} else {
-		return gm.conns[connectionId+1]
+func (gs *GameState) playerSeat(player *game.Player) int {
+	if gs.game.PlayerAt(0) == player {
+		return 0
 	}
+	return 1
 }
 
-func (gm *GameManager) getGame(connectionId int) *game.Game {
-	return gm.games[connectionId].game
+func (gs *GameState) getOtherConnectionId(connectionId int) int {
+	if connectionId == gs.connections[0] {
+		return gs.connections[1]
+	}
+	return gs.connections[0]
 }
 
-func (gm *GameManager) addConnection(conn net.Conn, connectionId int) {
-	gm.conns[connectionId] = conn
+func newGameManager(config Config) *GameManager {
+	return &GameManager{
+		config:   config,
+		games:    make(map[int]*GameState),
+		conns:    make(map[int]*protocol.Conn),
+		sessions: make(map[string]*GameState),
+		matches:  make(map[int]*GameState),
+		watching: make(map[int]*GameState),
+		lobby:    newLobby(),
+	}
 }
 
-// A new game is created as soon as the first client connects.
-func (gm *GameManager) handle(conn net.Conn, connectionId int) error {
-	log.Printf("[server %d] handle", connectionId)
+func (gm *GameManager) getGameState(connectionId int) *GameState {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
 
-	thisGame := gm.getGame(connectionId)
-	gameState := gm.games[connectionId]
+	return gm.games[connectionId]
+}
 
-outer:
-	for {
-		// if gm.getGame(connectionId).TurnCount > 1 {
-		// 	log.Printf("[server %d] game over", connectionId)
-		// 	return nil
-		// }
+func (gm *GameManager) getConn(connectionId int) *protocol.Conn {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
 
-		player := thisGame.GetPlayer(connectionId)
+	return gm.conns[connectionId]
+}
 
-		var playerState game.PlayerStatus
-		if player == nil {
-			playerState = game.WAITING_FOR_HELLO
-		} else {
-			playerState = player.State
-		}
+func (gm *GameManager) newConnectionId() int {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
 
-		switch playerState {
-		case game.WAITING_FOR_HELLO:
-			log.Printf("[server %d] WAITING_FOR_HELLO", connectionId)
-
-			msg, err := waitForMessage(conn)
-			if err != nil {
-				log.Printf("[server] error reading message: %v", err)
-				return err
-			}
-
-			response, err := gm.handleHelloCommand(msg, connectionId)
-			if err != nil {
-				log.Println("Error handling HELLO command:", err)
-				return err
-			}
-			sendMessage(conn, response)
-
-			player := thisGame.GetPlayer(connectionId)
-			player.State = game.SETUP_FLEET
-
-		case game.SETUP_FLEET:
-			log.Printf("[server %d] SETUP_FLEET", connectionId)
-			msg, err := waitForMessage(conn)
-			if err != nil {
-				log.Printf("[server] error reading message: %v", err)
-				return err
-			}
-
-			response, err := gm.handleShipCommand(msg, connectionId)
-			if err != nil {
-				log.Println("Error handling command:", err)
-				return err
-			}
-			sendMessage(conn, response)
-
-			if thisGame.IsReady() {
-				if player.GetPlayerCode() == "P1" {
-					player.State = game.PLAYING
-					time.Sleep(50 * time.Millisecond) // wait for the START message to be sent
-					sendMessage(conn, "TURN P1\n")
-				} else {
-					player.State = game.WAITING_FOR_ATTACK
-				}
-			}
-
-		case game.PLAYING:
-			for i := 0; i != game.TURN_MAX_ATTACKS; i++ {
-				log.Printf("[server %d] PLAYING", connectionId)
-				msg, err := waitForMessage(conn)
-				if err != nil {
-					log.Printf("[server %d] error reading message: %v", connectionId, err)
-					return err
-				}
-				log.Printf("[server %d] PLAYING received message: %s", connectionId, msg)
-				response, err := gm.handleAttackCommand(msg, connectionId)
-				if err != nil {
-					log.Printf("[server %d] error handling command: %v", connectionId, err)
-					return err
-				}
-
-				// broadcast to both players
-				sendMessage(conn, response)
-				sendMessage(gm.getOtherConn(connectionId), response)
-
-				if player.State == game.WON {
-					log.Printf("[server %d] game over", connectionId)
-
-					// wake opponent up
-					oponent := thisGame.GetOtherPlayer(connectionId)
-					gameState.readyChan <- oponent.GetPlayerCode()
-
-					continue outer
-				}
-			}
-			player.State = game.WAITING_FOR_ATTACK
-			oponent := thisGame.GetOtherPlayer(connectionId)
-			gameState.readyChan <- oponent.GetPlayerCode()
-
-		case game.WAITING_FOR_ATTACK:
-			log.Printf("[server %d] WAITING_FOR_ATTACK", connectionId)
-			// read from the channel
-			signalMsg := <-gameState.readyChan
-			log.Printf("[server %d] received signal: %s", connectionId, signalMsg)
-
-			if player.State != game.WAITING_FOR_ATTACK {
-				// state changed, probably other player won
-				log.Printf("[server %d] state changed, probably other player won", connectionId)
-				continue outer
-			}
-
-			if signalMsg != thisGame.GetPlayer(connectionId).GetPlayerCode() {
-				return fmt.Errorf("invalid state, should be %s", thisGame.GetPlayer(connectionId).GetPlayerCode())
-			}
-
-			time.Sleep(50 * time.Millisecond)
-			sendMessage(conn, fmt.Sprintf("TURN %s\n", player.GetPlayerCode()))
-			player.State = game.PLAYING
-
-		// won or lost
-		case game.WON, game.LOST:
-			log.Printf("[server %d] game is over, closing socket...", connectionId)
-			conn.Close()
-			break outer
-		}
-	}
+	gm.lastId++
+	return gm.lastId
+}
 
-	log.Printf("[server %d] game is over, exiting thread...", connectionId)
-	return nil
+func (gm *GameManager) addConnection(conn *protocol.Conn, connectionId int) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	gm.conns[connectionId] = conn
 }
 
-func (gm *GameManager) handleHelloCommand(msg string, connectionId int) (string, error) {
-	// assert that the message is a HELLO command
-	if !strings.HasPrefix(msg, "HELLO") {
-		panic("Should be a HELLO command")
-	}
+// removeConnection releases everything the manager holds for a connection.
+// Once both players of a game are removed the GameState is unreachable.
+func (gm *GameManager) removeConnection(connectionId int) {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
 
-	parts := strings.Fields(msg)
-	if len(parts) != 2 {
-		return "ERROR invalid HELLO command\n", fmt.Errorf("invalid HELLO command")
+	if gameState, exists := gm.games[connectionId]; exists {
+		gm.lobby.remove(gameState)
 	}
+	delete(gm.games, connectionId)
+	delete(gm.watching, connectionId)
+	delete(gm.conns, connectionId)
+}
 
-	playerName := parts[1]
-	if len(playerName) < 1 || len(playerName) > 20 {
-		return "ERROR invalid player name\n", fmt.Errorf("invalid player name")
+// send writes a message to a connection, if it is still around.
+func (gm *GameManager) send(connectionId int, message string) {
+	if conn := gm.getConn(connectionId); conn != nil {
+		sendMessage(conn, message)
 	}
+}
 
-	// gm.mu.Lock()
-	// defer gm.mu.Unlock()
-	//
-	// // With HELLO, the game should not exist for this connectionId
-	// if _, exists := gm.games[connectionId]; exists {
-	// 	log.Println("Game already exists for connectionId:", connectionId)
-	// 	return "ERROR Game already exists\n", fmt.Errorf("game already exists for connectionId %d", connectionId)
-	// }
-	//
-	// // if connectionId is even, use the game of previous connectionId
-	// if connectionId%2 == 0 {
-	// 	if prevGameState, exists := gm.games[connectionId-1]; exists {
-	// 		log.Println("second player first message, associating with already created game")
-	// 		prevGameState.connections[1] = connectionId
-	// 		gm.games[connectionId] = prevGameState
-	// 	} else {
-	// 		return "ERROR No game found for previous player\n", fmt.Errorf("no game found for previous player")
-	// 	}
-	// } else {
-	// 	log.Println("Creating new game for connectionId:", connectionId)
-	// 	// Create a new game state
-	// 	gameState := &GameState{
-	// 		game:        game.NewGame(),
-	// 		connections: [2]int{connectionId, -1},
-	// 		readyChan:   make(chan int, 1),
-	// 	}
-	//
-	// 	gm.games[connectionId] = gameState
-	// }
-
-	player := gm.getGame(connectionId).AddPlayer(connectionId, playerName)
-
-	switch connectionId {
-	case 1:
-		return fmt.Sprintf("WELCOME %s %s\n", player.GetPlayerCode(), playerName), nil
-	case 2:
-		return fmt.Sprintf("WELCOME %s %s\n", player.GetPlayerCode(), playerName), nil
-	default:
-		panic("Only two players are allowed")
+// broadcast sends a message to both players of a game and its spectators.
+func (gm *GameManager) broadcast(gameState *GameState, message string) {
+	for _, connectionId := range gameState.connections {
+		gm.send(connectionId, message)
 	}
+	gm.spectate(gameState, message)
 }
 
-func (gm *GameManager) handleShipCommand(msg string, connectionId int) (string, error) {
-	// READY - sent by the client when all ships are placed
-	if strings.HasPrefix(msg, "READY") {
-		return gm.handleReadyCommand(msg, connectionId)
+// endGame hangs up on both players and forgets their session tokens. Their
+// read loops then notice the closed connection and clean up after themselves.
+// Spectators go back to the lobby.
+func (gm *GameManager) endGame(gameState *GameState) {
+	gm.stopClocks(gameState)
+	gm.closeMatch(gameState)
+	for seat, connectionId := range gameState.connections {
+		gm.dropSession(gameState, seat)
+		if conn := gm.getConn(connectionId); conn != nil {
+			conn.Close()
+		}
 	}
+}
+
+// handle reads commands from a connection until it is closed. A connection
+// starts in the lobby, where it can LIST, CREATE, JOIN or QUEUE. A HELLO sent
+// from the lobby joins the matchmaking queue.
+func (gm *GameManager) handle(conn *protocol.Conn, connectionId int) error {
+	log.Printf("[server %d] handle", connectionId)
+	defer gm.disconnect(connectionId)
+	defer conn.Close()
 
-	// assert that the message is a SHIP command
-	if !strings.HasPrefix(msg, "SHIP") {
-		panic("Should be a SHIP command")
+	for {
+		msg, err := waitForMessage(conn)
+		if err != nil {
+			log.Printf("[server %d] connection closed, exiting thread: %v", connectionId, err)
+			return err
+		}
+
+		cmd, err := parseCommand(msg)
+		if err == nil {
+			err = gm.dispatch(connectionId, cmd)
+		}
+		if err != nil {
+			log.Printf("[server %d] error handling command: %v", connectionId, err)
+			sendMessage(conn, errorResponse(err))
+		}
 	}
+}
+
+func (gm *GameManager) handleHelloCommand(connectionId int, cmd Command) error {
+	hello := cmd.(HelloCommand)
 
-	parts := strings.Fields(msg)
-	if len(parts) != 5 {
-		return "ERROR Invalid SHIP command\n", fmt.Errorf("invalid SHIP command")
+	gameState := gm.getGameState(connectionId)
+	if gameState != nil && hello.AI != "" {
+		return fmt.Errorf("%w: VS_AI is only allowed from the lobby", errUnexpectedCommand)
 	}
-	shipType := parts[1]
-	x := parts[2]
-	y := parts[3]
-	if !isValidShipType(shipType) {
-		return "ERROR Invalid ship type\n", fmt.Errorf("invalid ship type")
+	if gameState != nil && hello.Options != (GameOptions{}) {
+		return fmt.Errorf("%w: options are set when the game is created", errUnexpectedCommand)
 	}
-	if !isValidDirection(parts[4]) {
-		return "ERROR Invalid direction\n", fmt.Errorf("invalid direction")
+	if gameState == nil {
+		if _, err := gm.rules(hello.Options); err != nil {
+			return err
+		}
+		// HELLO straight from the lobby means "find me an opponent", the
+		// game comes back locked
+		if hello.AI != "" {
+			gameState = gm.startAIGame(connectionId, hello.AI, hello.Options)
+		} else {
+			gameState = gm.enqueue(connectionId, hello.Options)
+		}
+		defer gameState.mu.Unlock()
 	}
 
-	// Get player
-	player := gm.getGame(connectionId).GetPlayer(connectionId)
-	if player == nil {
-		return "ERROR hello command not received yet\n", fmt.Errorf("hello command not received yet")
+	seat := gameState.getSeat(connectionId)
+	// the game was abandoned between JOIN and HELLO
+	if gameState.connections[seat] != connectionId {
+		return errGameAbandoned
 	}
-
-	err := player.AddShip(shipType, x, y, parts[4])
-	if err != nil {
-		return "ERROR Invalid placement\n", fmt.Errorf("invalid placement")
+	if err := gm.apply(gameState, game.Join{Seat: seat, Id: connectionId, Name: hello.Name}); err != nil {
+		return err
+	}
+	player := gameState.game.PlayerAt(seat)
+	token := gm.newSession(gameState, seat)
+	if gameState.game.PlayerAt(1-seat) != nil {
+		gm.startSetupClock(gameState, 0)
+		gm.startSetupClock(gameState, 1)
 	}
 
-	return fmt.Sprintf("OK SHIP %s\n", shipType), nil
+	gm.send(connectionId, fmt.Sprintf("WELCOME %s %s %s %s", player.GetPlayerCode(), hello.Name, token, rulesMessage(gameState.game)))
+	return nil
 }
 
-func (gm *GameManager) handleReadyCommand(_ string, connectionId int) (string, error) {
-	log.Printf("[server %d] handleReadyCommand", connectionId)
+func (gm *GameManager) handleShipCommand(connectionId int, cmd Command) error {
+	ship := cmd.(ShipCommand)
 
-	player := gm.games[connectionId].game.GetPlayer(connectionId)
-	if player == nil {
-		log.Println("Player not found")
-		return "ERROR player not found\n", fmt.Errorf("player not found")
-	}
-	if player.Fleet.Ready {
-		log.Println("Player already ready")
-		return "ERROR player already ready\n", fmt.Errorf("player already ready")
-	}
-	if player.Fleet.UnitSize < game.FLEET_UNIT_SIZE {
-		log.Println("Player fleet not full")
-		return "ERROR player fleet not full\n", fmt.Errorf("player fleet not full")
-	}
+	gameState := gm.getGameState(connectionId)
+	placement := game.Placement{ShipType: ship.ShipType, X: ship.X, Y: ship.Y, Direction: ship.Direction}
+	return gm.apply(gameState, game.PlaceShip{Seat: gameState.getSeat(connectionId), Placement: placement})
+}
+
+// handleFleetCommand replaces the player's layout with a complete fleet, or
+// leaves it alone if any ship is rejected.
+func (gm *GameManager) handleFleetCommand(connectionId int, cmd Command) error {
+	fleet := cmd.(FleetCommand)
 
-	player.Fleet.Ready = true
-
-	if gm.games[connectionId].game.IsReady() {
-		// write to the channel to notify the other player
-		log.Printf("[server %d] both players are ready", connectionId)
-		gm.games[connectionId].readyChan <- player.GetPlayerCode()
-		log.Println("Sending START message to player", connectionId)
-		// sendMessage(connectionId, "START P1\n")
-		return "START P1\n", nil
-	} else {
-		// else wait for the other player to be ready
-		log.Printf("[server %d] player %s is ready", connectionId, player.GetPlayerCode())
-		<-gm.games[connectionId].readyChan
-
-		log.Println("Sending START message to player", connectionId)
-		// gm.sendMessage(connectionId, player.GetPlayerCode(), "START P1\n")
-		return "START P1\n", nil
+	placements := make([]game.Placement, len(fleet.Ships))
+	for i, ship := range fleet.Ships {
+		placements[i] = game.Placement{ShipType: ship.ShipType, X: ship.X, Y: ship.Y, Direction: ship.Direction}
 	}
-	// send the TURN message to each player
-	// time.Sleep(50 * time.Millisecond) // wait for the START message to be sent
-	// return "TURN P1\n", nil
+	gameState := gm.getGameState(connectionId)
+	return gm.apply(gameState, game.PlaceFleet{Seat: gameState.getSeat(connectionId), Placements: placements})
 }
 
-//
-// func (gm *GameManager) sendMessage(connectionId int, playerCode string, msg string) error {
-// 	conn := gm.conns[connectionId]
-// 	if conn == nil {
-// 		log.Println("Connection not found for player", connectionId)
-// 		return fmt.Errorf("connection not found for player %d", connectionId)
-// 	}
-// 	_, err := conn.Write([]byte(msg))
-// 	if err != nil {
-// 		log.Println("Error sending READY message to player", playerCode)
-// 		return fmt.Errorf("error sending READY message to player %s", playerCode)
-// 	}
-// 	return nil
-// }
-
-// func (gm *GameManager) sendMessageAndWaitForResponse(connectionId int, playerCode string, msg string) (string, error) {
-// 	var conn net.Conn = gm.conns[connectionId]
-// 	if conn == nil {
-// 		log.Println("Connection not found for player", connectionId)
-// 		return "", fmt.Errorf("connection not found for player %d", connectionId)
-// 	}
-// 	_, err := conn.Write([]byte(msg))
-// 	if err != nil {
-// 		log.Println("Error sending READY message to player", playerCode)
-// 		return "", fmt.Errorf("error sending READY message to player %s", playerCode)
-// 	}
-//
-// 	buf := make([]byte, 1024)
-// 	n, err := conn.Read(buf)
-// 	if err != nil {
-// 		log.Println("Error reading response from player", playerCode)
-// 		return "", fmt.Errorf("error reading response from player %s", playerCode)
-// 	}
-//
-// 	return string(buf[:n]), nil
-// }
-
-// func (gm *GameManager) sendYourTurnMessage(connectionId int, playerCode string) error {
-// 	msg := fmt.Sprintf("TURN %s\n", playerCode)
-// 	err := gm.sendMessage(connectionId, playerCode, msg)
-// 	if err != nil {
-// 		log.Println("Error sending TURN message to player", playerCode)
-// 		return fmt.Errorf("error sending TURN message to player %s", playerCode)
-// 	}
-//
-// 	return nil
-// }
-
-// func (gm *GameManager) handleTurn(msg string, connectionId int) error {
-// 	var conn net.Conn = gm.conns[connectionId]
-// 	if conn == nil {
-// 		log.Println("Connection not found for player", connectionId)
-// 		return fmt.Errorf("connection not found for player %d", connectionId)
-// 	}
-// 	// send the START message to the other player
-// 	_, err := conn.Write([]byte("START P1\n"))
-// 	if err != nil {
-// 		log.Println("Error sending START message to player", connectionId)
-// 		return fmt.Errorf("error sending START message to player %d", connectionId)
-// 	}
-//
-// 	return nil
-// }
-
-func (gm *GameManager) handleAttackCommand(msg string, connectionId int) (string, error) {
-	// assert that the message is a ATTACK command
-	if !strings.HasPrefix(msg, "ATTACK") {
-		panic("Should be a ATTACK command")
-	}
+func (gm *GameManager) handleUnshipCommand(connectionId int, cmd Command) error {
+	unship := cmd.(UnshipCommand)
 
-	parts := strings.Fields(msg)
-	if len(parts) != 3 {
-		return "ERROR Invalid ATTACK command\n", fmt.Errorf("invalid ATTACK command")
-	}
+	gameState := gm.getGameState(connectionId)
+	return gm.apply(gameState, game.RemoveShip{Seat: gameState.getSeat(connectionId), At: game.Vector2{X: unship.X, Y: unship.Y}})
+}
 
-	thisGame := gm.getGame(connectionId)
+func (gm *GameManager) handleResetCommand(connectionId int, _ Command) error {
+	gameState := gm.getGameState(connectionId)
+	return gm.apply(gameState, game.ResetFleet{Seat: gameState.getSeat(connectionId)})
+}
 
-	player := thisGame.GetPlayer(connectionId)
-	if player == nil {
-		return "ERROR hello command not received yet\n", fmt.Errorf("hello command not received yet")
-	}
+// handleReadyCommand locks the player's fleet in. The game starts as soon as
+// both fleets are ready: both players get START and P1 gets the first TURN.
+func (gm *GameManager) handleReadyCommand(connectionId int, _ Command) error {
+	gameState := gm.getGameState(connectionId)
+	return gm.apply(gameState, game.Ready{Seat: gameState.getSeat(connectionId)})
+}
 
-	opponent := thisGame.GetOtherPlayer(connectionId)
-	if opponent == nil {
-		return "ERROR opponent not found\n", fmt.Errorf("opponent not found")
-	}
+// handleAttackCommand fires a shot and broadcasts the result to both players.
+// When the attacker runs out of shots the turn passes to the opponent.
+func (gm *GameManager) handleAttackCommand(connectionId int, cmd Command) error {
+	attack := cmd.(AttackCommand)
 
-	if !thisGame.IsPlayersTurn(player) {
-		return "ERROR not your turn\n", fmt.Errorf("not your turn")
-	}
+	gameState := gm.getGameState(connectionId)
+	seat := gameState.getSeat(connectionId)
+	return gm.fire(gameState, seat, game.Attack{Seat: seat, Target: game.Vector2{X: attack.X, Y: attack.Y}})
+}
 
-	// lastAttack := false
+// handleSalvoCommand fires all shots of the turn at once.
+func (gm *GameManager) handleSalvoCommand(connectionId int, cmd Command) error {
+	salvo := cmd.(SalvoCommand)
 
-	log.Printf("Game turn count: %d", thisGame.TurnCount)
-	log.Printf("Player turn count: %d", player.TurnCount)
-	if player.TurnCount >= game.TURN_MAX_ATTACKS {
-		// lastAttack = true
-		player.TurnCount = 1
-		gm.getGame(connectionId).TurnCount++
-	} else {
-		player.TurnCount++
-	}
+	gameState := gm.getGameState(connectionId)
+	seat := gameState.getSeat(connectionId)
+	return gm.fire(gameState, seat, game.Salvo{Seat: seat, Targets: salvo.Targets})
+}
 
-	x := parts[1]
-	y := parts[2]
-	hit, sunkShipType := opponent.ReceiveAttack(x, y)
+// fire applies an attack or an ability. The player's clock stops while it is
+// resolved and runs on if the turn is still theirs afterwards.
+// Must be called with the game locked.
+func (gm *GameManager) fire(gameState *GameState, seat int, cmd game.Command) error {
+	thisGame := gameState.game
+	player := thisGame.PlayerAt(seat)
+	if thisGame.PlayerAt(1-seat) == nil {
+		return errOpponentNotFound
+	}
 
-	// check if the game is over
-	if opponent.AllShipsSunk() {
-		log.Printf("[server %d] Game over, player %s wins", connectionId, player.GetPlayerCode())
-		player.State = game.WON
-		opponent.State = game.LOST
-		return fmt.Sprintf("WIN %s\n", player.GetPlayerCode()), nil
+	thisGame.StopClock(player, time.Now())
+	if thisGame.BankExhausted(player) {
+		gm.forfeit(gameState, seat, "TIMEOUT")
+		return nil
 	}
 
-	var attackResult string
-	if hit {
-		if sunkShipType != nil {
-			// sunk
-			attackResult = fmt.Sprintf("SUNK %s %s %s\n", x, y, *sunkShipType)
-		} else {
-			// hit but not sunk
-			attackResult = fmt.Sprintf("HIT %s %s\n", x, y)
-		}
-	} else {
-		attackResult = fmt.Sprintf("MISS %s %s\n", x, y)
+	turnCount := thisGame.TurnCount
+	if err := gm.apply(gameState, cmd); err != nil {
+		// a rejected shot does not stop the clock
+		thisGame.StartClock(player, time.Now())
+		return err
+	}
+	if !thisGame.IsOver() && thisGame.TurnCount == turnCount {
+		gm.startTurnClock(gameState, player)
 	}
+	return nil
+}
 
-	// if lastAttack {
-	// 	gm.sendMessage(connectionId, player.GetPlayerCode(), attackResult)
-	// 	time.Sleep(50 * time.Millisecond) // wait for the attack result to be sent
-	// 	gm.sendMessage(gm.games[connectionId].getOtherConnectionId(connectionId), opponent.GetPlayerCode(), "TURN "+opponent.GetPlayerCode()+"\n")
-	// 	// return "TURN " + opponent.GetPlayerCode() + "\n", nil
-	// 	return attackResult, nil
-	// } else {
-	return attackResult, nil
-	// }
+// rulesMessage lists the rules of a game as key=value pairs, the same way
+// they are negotiated.
+func rulesMessage(thisGame *game.Game) string {
+	return fmt.Sprintf("size=%d rules=%s adjacency=%s repeat=%s turns=%s",
+		thisGame.BoardSize, thisGame.Rules.Name, thisGame.Rules.Adjacency, thisGame.Rules.Repeat, thisGame.Rules.Turns)
 }
 
-func isValidDirection(s string) bool {
-	if len(s) != 1 {
-		return false
-	}
-	if s[0] != 'H' && s[0] != 'V' {
-		return false
+// shotMessage formats the outcome of an attack as HIT, MISS or SUNK.
+func shotMessage(shot game.Shot) string {
+	switch {
+	case shot.Sunk != "":
+		return fmt.Sprintf("SUNK %d %d %s", shot.Position.X, shot.Position.Y, shot.Sunk)
+	case shot.Hit:
+		return fmt.Sprintf("HIT %d %d", shot.Position.X, shot.Position.Y)
+	default:
+		return fmt.Sprintf("MISS %d %d", shot.Position.X, shot.Position.Y)
 	}
-	return true
 }
 
-func isValidShipType(shipType string) bool {
-	shipTypes := [5]string{"CARRIER", "BATTLESHIP", "CRUISER", "DESTROYER", "SUBMARINE"}
-	for _, st := range shipTypes {
-		if shipType == st {
-			return true
+// handleBoardCommand sends the player what their shots revealed of the
+// opponent's board, one row per line from the top, followed by END.
+func (gm *GameManager) handleBoardCommand(connectionId int, _ Command) error {
+	thisGame := gm.getGameState(connectionId).game
+	opponent := thisGame.GetOtherPlayer(connectionId)
+	if opponent == nil {
+		return errOpponentNotFound
+	}
+
+	gm.send(connectionId, fmt.Sprintf("BOARD %d", thisGame.BoardSize))
+	for _, row := range opponent.Fleet.ShotGrid() {
+		var line strings.Builder
+		for _, cell := range row {
+			line.WriteString(cell.String())
 		}
+		gm.send(connectionId, line.String())
 	}
-	return false
+	gm.send(connectionId, "END")
+	return nil
 }
 
-func waitForMessage(conn net.Conn) (string, error) {
-	buf := make([]byte, 1024)
-	n, err := conn.Read(buf)
-	if err != nil {
-		log.Printf("[server] error reading response from player: %v", err)
-		return "", err
+// handleQuitCommand says goodbye. Quitting a game means forfeiting it.
+func (gm *GameManager) handleQuitCommand(connectionId int, _ Command) error {
+	conn := gm.getConn(connectionId)
+	sendMessage(conn, "BYE")
+
+	if gameState := gm.getGameState(connectionId); gameState != nil {
+		gm.leave(gameState, connectionId, true)
+	}
+	conn.Close()
+	return nil
+}
+
+func waitForMessage(conn *protocol.Conn) (string, error) {
+	for {
+		msg, err := conn.ReadLine()
+		if errors.Is(err, protocol.ErrLineTooLong) {
+			log.Printf("[server] waitForMessage - discarding line longer than %d bytes", protocol.MaxLineLength)
+			sendMessage(conn, errorResponse(err))
+			continue
+		}
+		if err != nil {
+			log.Printf("[server] error reading response from player: %v", err)
+			return "", err
+		}
+		if strings.TrimSpace(msg) == "" {
+			continue
+		}
+		log.Printf("[server] waitForMessage - received: %s", msg)
+		return msg, nil
 	}
-	response := string(buf[:n])
-	log.Printf("[server] waitForMessage - received: %s", response)
-	return response, nil
 }
 
-func sendMessage(conn net.Conn, message string) error {
+func sendMessage(conn *protocol.Conn, message string) error {
 	log.Printf("[server] sendMessage - sending: %s", message)
-	_, err := conn.Write([]byte(message))
+	err := conn.WriteLine(message)
 	if err != nil {
 		log.Println("Error sending message:", err)
 		return err
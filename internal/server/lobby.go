@@ -0,0 +1,233 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// Lobby holds the games that are still waiting for their second player.
+// Players either meet in a named room or are paired by the matchmaking
+// queue. The lobby is guarded by the GameManager mutex.
+type Lobby struct {
+	rooms map[string]*GameState      // room name -> game waiting for an opponent
+	queue map[GameOptions]*GameState // matchmaking games waiting for an opponent
+}
+
+func newLobby() *Lobby {
+	return &Lobby{
+		rooms: make(map[string]*GameState),
+		queue: make(map[GameOptions]*GameState),
+	}
+}
+
+// presets are the rulesets games may be created with.
+func (gm *GameManager) presets() game.Presets {
+	if gm.config.Rulesets == nil {
+		return game.Rulesets
+	}
+	return gm.config.Rulesets
+}
+
+// rules builds the ruleset a game with options is played with. It fails
+// when the options name a preset the server does not have, or when the fleet
+// does not fit the board they ask for.
+func (gm *GameManager) rules(options GameOptions) (*game.Ruleset, error) {
+	options = options.withDefaults()
+	rules, err := gm.presets().Lookup(options.Rules)
+	if err != nil {
+		return nil, err
+	}
+	// the policies were checked by parseOptions
+	if options.Adjacency != "" {
+		adjacency, _ := game.ParseAdjacencyPolicy(options.Adjacency)
+		rules = rules.WithAdjacency(adjacency)
+	}
+	if options.Repeat != "" {
+		repeat, _ := game.ParseRepeatPolicy(options.Repeat)
+		rules = rules.WithRepeat(repeat)
+	}
+	if options.Turns != "" {
+		turns, _ := game.ParseTurnPolicy(options.Turns)
+		rules = rules.WithTurns(turns)
+	}
+	// the options may shrink the board or keep ships further apart than the
+	// preset was checked for
+	if err := rules.CheckFits(options.BoardSize); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// newGameState creates a game for connectionId and registers it, so it can
+// be watched. The options must have been checked with rules. Must be called
+// with the manager locked.
+func (gm *GameManager) newGameState(connectionId int, options GameOptions) *GameState {
+	options = options.withDefaults()
+	thisGame := game.NewGame()
+	thisGame.TimeControl = gm.config.TimeControl
+	thisGame.BoardSize = options.BoardSize
+	thisGame.Rules, _ = gm.rules(options)
+
+	gm.lastGame++
+	gameState := &GameState{
+		id:          gm.lastGame,
+		game:        thisGame,
+		connections: [2]int{connectionId, -1},
+	}
+	gm.matches[gameState.id] = gameState
+	return gameState
+}
+
+// remove drops a waiting game from the lobby, e.g. when its only player
+// disconnects before an opponent arrived.
+func (l *Lobby) remove(gameState *GameState) {
+	for options, waiting := range l.queue {
+		if waiting == gameState {
+			delete(l.queue, options)
+		}
+	}
+	for name, room := range l.rooms {
+		if room == gameState {
+			delete(l.rooms, name)
+		}
+	}
+}
+
+func (l *Lobby) roomNames() []string {
+	names := make([]string, 0, len(l.rooms))
+	for name := range l.rooms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (gm *GameManager) handleListCommand(connectionId int, _ Command) error {
+	gm.mu.RLock()
+	names := gm.lobby.roomNames()
+	gm.mu.RUnlock()
+
+	gm.send(connectionId, strings.Join(append([]string{"ROOMS"}, names...), " "))
+	return nil
+}
+
+func (gm *GameManager) handleCreateCommand(connectionId int, cmd Command) error {
+	room := cmd.(CreateCommand).Room
+	if _, err := gm.rules(cmd.(CreateCommand).Options); err != nil {
+		return err
+	}
+	if err := gm.createRoom(room, connectionId, cmd.(CreateCommand).Options); err != nil {
+		return err
+	}
+
+	gm.send(connectionId, fmt.Sprintf("OK CREATE %s", room))
+	return nil
+}
+
+func (gm *GameManager) handleJoinCommand(connectionId int, cmd Command) error {
+	room := cmd.(JoinCommand).Room
+	if err := gm.joinRoom(room, connectionId); err != nil {
+		return err
+	}
+
+	gm.send(connectionId, fmt.Sprintf("OK JOIN %s", room))
+	return nil
+}
+
+func (gm *GameManager) handleQueueCommand(connectionId int, cmd Command) error {
+	if _, err := gm.rules(cmd.(QueueCommand).Options); err != nil {
+		return err
+	}
+	gameState := gm.enqueue(connectionId, cmd.(QueueCommand).Options)
+	gameState.mu.Unlock()
+
+	gm.send(connectionId, "OK QUEUE")
+	return nil
+}
+
+func (gm *GameManager) createRoom(room string, connectionId int, options GameOptions) error {
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	if _, exists := gm.lobby.rooms[room]; exists {
+		return fmt.Errorf("%w: %s", errRoomExists, room)
+	}
+
+	log.Printf("[server %d] creating room %s", connectionId, room)
+	gameState := gm.newGameState(connectionId, options)
+	gm.lobby.rooms[room] = gameState
+	gm.games[connectionId] = gameState
+	return nil
+}
+
+func (gm *GameManager) joinRoom(room string, connectionId int) error {
+	gm.mu.Lock()
+	gameState, exists := gm.lobby.rooms[room]
+	if !exists {
+		gm.mu.Unlock()
+		return fmt.Errorf("%w: %s", errRoomNotFound, room)
+	}
+
+	log.Printf("[server %d] joining room %s", connectionId, room)
+	delete(gm.lobby.rooms, room)
+	gm.games[connectionId] = gameState
+	gm.mu.Unlock()
+
+	if !gm.seat(gameState, connectionId) {
+		return fmt.Errorf("%w: %s", errRoomNotFound, room)
+	}
+	gameState.mu.Unlock()
+	return nil
+}
+
+// enqueue pairs the connection with the player waiting in the matchmaking
+// queue for the same options, or makes it the one waiting if there is none.
+// The game is returned locked, so the caller can go on with it before the
+// opponent leaves.
+func (gm *GameManager) enqueue(connectionId int, options GameOptions) *GameState {
+	options = options.withDefaults()
+
+	gm.mu.Lock()
+	gameState := gm.lobby.queue[options]
+	if gameState == nil {
+		log.Printf("[server %d] waiting in queue", connectionId)
+		gameState = gm.newGameState(connectionId, options)
+		// nobody else can know the game yet, this does not wait
+		gameState.mu.Lock()
+		gm.games[connectionId] = gameState
+		gm.lobby.queue[options] = gameState
+		gm.mu.Unlock()
+		return gameState
+	}
+
+	log.Printf("[server %d] paired from queue", connectionId)
+	gm.games[connectionId] = gameState
+	delete(gm.lobby.queue, options)
+	gm.mu.Unlock()
+
+	if !gm.seat(gameState, connectionId) {
+		return gm.enqueue(connectionId, options)
+	}
+	return gameState
+}
+
+// seat makes the connection the second player of a game. The game lock is
+// taken outside of the manager lock, the first player may be using it, and
+// is kept when seating succeeds. It fails if the first player abandoned the
+// game in the meantime.
+func (gm *GameManager) seat(gameState *GameState, connectionId int) bool {
+	gameState.mu.Lock()
+	if gameState.connections[0] == -1 {
+		gameState.mu.Unlock()
+		gm.mu.Lock()
+		delete(gm.games, connectionId)
+		gm.mu.Unlock()
+		return false
+	}
+	gameState.connections[1] = connectionId
+	return true
+}
@@ -5,7 +5,7 @@ import (
 	"net"
 	"sync"
 
-	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/protocol"
 )
 
 type Server struct {
@@ -56,54 +56,31 @@ func (s *Server) Start() {
 	defer ln.Close()
 	log.Printf("[server] server started on %s", s.address)
 
-	connectionId := 0
-
 	for {
 		log.Printf("[server] waiting for connection...")
 		conn, err := ln.Accept()
 		if err != nil {
 			log.Fatal(err)
 		}
-		connectionId++
+		connectionId := s.gm.newConnectionId()
 		log.Printf("[server %d] new connection from %s", connectionId, conn.RemoteAddr())
 
-		s.gm.addConnection(conn, connectionId)
-		s.initializeGame(connectionId)
+		protocolConn := protocol.NewConn(conn)
+		s.gm.addConnection(protocolConn, connectionId)
 
 		log.Printf("[server %d] handling connection...", connectionId)
-		go s.gm.handle(conn, connectionId)
+		go s.gm.handle(protocolConn, connectionId)
 		// go s.handleConnection(conn, connectionId)
 	}
 }
 
 func NewServer(addressString string) Server {
-	return Server{
-		address: addressString,
-		gm:      newGameManager(),
-	}
+	return NewServerWithConfig(addressString, DefaultConfig())
 }
 
-func (s *Server) initializeGame(connectionId int) {
-	// s.gm.mu.Lock()
-	// defer s.gm.mu.Unlock()
-
-	if connectionId%2 == 0 {
-		if prevGameState, exists := s.gm.games[connectionId-1]; exists {
-			log.Printf("[server %d] second player connected", connectionId)
-			prevGameState.connections[1] = connectionId
-			s.gm.games[connectionId] = prevGameState
-		} else {
-			log.Printf("[server %d] ERROR No game found for previous player", connectionId)
-			return
-		}
-	} else {
-		log.Printf("[server %d] first player connected, creating new game", connectionId)
-		gameState := &GameState{
-			game:        game.NewGame(),
-			connections: [2]int{connectionId, -1},
-			readyChan:   make(chan string, 1),
-		}
-
-		s.gm.games[connectionId] = gameState
+func NewServerWithConfig(addressString string, config Config) Server {
+	return Server{
+		address: addressString,
+		gm:      newGameManager(config),
 	}
 }
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// newSession issues the token a player needs to RESUME their seat.
+// Must be called with the game locked.
+func (gm *GameManager) newSession(gameState *GameState, seat int) string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	gameState.tokens[seat] = token
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	gm.sessions[token] = gameState
+
+	return token
+}
+
+// dropSession invalidates the token of a seat. Must be called with the game
+// locked.
+func (gm *GameManager) dropSession(gameState *GameState, seat int) {
+	if timer := gameState.graceTimers[seat]; timer != nil {
+		timer.Stop()
+		gameState.graceTimers[seat] = nil
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	delete(gm.sessions, gameState.tokens[seat])
+	gameState.tokens[seat] = ""
+}
+
+// disconnect cleans up after a closed connection.
+func (gm *GameManager) disconnect(connectionId int) {
+	if gameState := gm.getGameState(connectionId); gameState != nil {
+		gameState.mu.Lock()
+		gm.leave(gameState, connectionId, false)
+		gameState.mu.Unlock()
+	}
+	if gameState := gm.getWatchedGame(connectionId); gameState != nil {
+		gameState.mu.Lock()
+		gm.unwatch(gameState, connectionId)
+		gameState.mu.Unlock()
+	}
+	gm.removeConnection(connectionId)
+}
+
+// leave handles a player walking away from their game. Before both players
+// said HELLO the game is simply abandoned. After that a player who QUITs
+// forfeits right away, while one who dropped keeps their seat for the resume
+// grace period and forfeits when it runs out. Must be called with the game
+// locked.
+func (gm *GameManager) leave(gameState *GameState, connectionId int, quit bool) {
+	seat := gameState.getSeat(connectionId)
+	if gameState.connections[seat] != connectionId {
+		return
+	}
+	player := gameState.game.PlayerAt(seat)
+	opponent := gameState.game.PlayerAt(1 - seat)
+
+	switch {
+	case player != nil && (player.State == game.WON || player.State == game.LOST):
+		// endGame already took care of everything
+	case player == nil || opponent == nil:
+		gm.abandon(gameState, seat)
+	case quit:
+		gm.forfeit(gameState, seat, "FORFEIT")
+	default:
+		gm.suspend(gameState, seat)
+	}
+}
+
+// abandon tears down a game that never got going. It leaves the lobby at
+// once, so nobody can join it any more, and an opponent who is still
+// connected is told so and sent back to the lobby. Must be called with the
+// game locked.
+func (gm *GameManager) abandon(gameState *GameState, seat int) {
+	log.Printf("[server] game abandoned by seat %d", seat)
+	gm.stopClocks(gameState)
+	gm.spectate(gameState, "ABANDONED")
+	gm.closeMatch(gameState)
+	gameState.connections[seat] = -1
+	gm.dropSession(gameState, 0)
+	gm.dropSession(gameState, 1)
+
+	opponentId := gameState.connections[1-seat]
+	gameState.connections[1-seat] = -1
+
+	gm.mu.Lock()
+	gm.lobby.remove(gameState)
+	if opponentId != -1 {
+		delete(gm.games, opponentId)
+	}
+	gm.mu.Unlock()
+
+	if opponentId != -1 {
+		gm.send(opponentId, "ABANDONED")
+	}
+}
+
+// forfeit ends a running game in favour of the opponent of seat. The reason,
+// FORFEIT or TIMEOUT, is sent along with the WIN. Must be called with the
+// game locked.
+func (gm *GameManager) forfeit(gameState *GameState, seat int, reason string) {
+	log.Printf("[server] player %s loses: %s", gameState.game.PlayerAt(seat).GetPlayerCode(), reason)
+	if err := gm.apply(gameState, game.Forfeit{Seat: seat, Reason: reason}); err != nil {
+		log.Printf("[server] could not forfeit: %v", err)
+	}
+}
+
+// suspend frees the seat of a dropped player and starts the grace timer.
+// Must be called with the game locked.
+func (gm *GameManager) suspend(gameState *GameState, seat int) {
+	player := gameState.game.PlayerAt(seat)
+
+	log.Printf("[server] player %s dropped, keeping seat for %v", player.GetPlayerCode(), gm.config.ResumeGrace)
+	gameState.connections[seat] = -1
+	token := gameState.tokens[seat]
+	gameState.graceTimers[seat] = time.AfterFunc(gm.config.ResumeGrace, func() {
+		gm.expireSession(gameState, seat, token)
+	})
+
+	gm.send(gameState.connections[1-seat], fmt.Sprintf("DISCONNECTED %s", player.GetPlayerCode()))
+	gm.spectate(gameState, fmt.Sprintf("DISCONNECTED %s", player.GetPlayerCode()))
+}
+
+// expireSession makes a player who did not come back in time forfeit.
+func (gm *GameManager) expireSession(gameState *GameState, seat int, token string) {
+	gameState.mu.Lock()
+	defer gameState.mu.Unlock()
+
+	// the player came back just before the timer fired
+	if gameState.connections[seat] != -1 || gameState.tokens[seat] != token {
+		return
+	}
+
+	log.Printf("[server] session of seat %d expired", seat)
+	gm.forfeit(gameState, seat, "FORFEIT")
+}
+
+// handleResumeCommand reattaches a new connection to the seat of a player
+// who dropped, then sends them a snapshot of the game.
+func (gm *GameManager) handleResumeCommand(connectionId int, cmd Command) error {
+	token := cmd.(ResumeCommand).Token
+
+	gm.mu.RLock()
+	gameState := gm.sessions[token]
+	gm.mu.RUnlock()
+	if gameState == nil {
+		return errInvalidSession
+	}
+
+	gameState.mu.Lock()
+	defer gameState.mu.Unlock()
+
+	seat := slices.Index(gameState.tokens[:], token)
+	if seat == -1 {
+		return errInvalidSession
+	}
+	if gameState.connections[seat] != -1 {
+		return errSessionInUse
+	}
+
+	gameState.graceTimers[seat].Stop()
+	gameState.graceTimers[seat] = nil
+	gameState.connections[seat] = connectionId
+	player := gameState.game.ReattachPlayer(seat, connectionId)
+
+	gm.mu.Lock()
+	gm.games[connectionId] = gameState
+	gm.mu.Unlock()
+
+	log.Printf("[server %d] player %s resumed", connectionId, player.GetPlayerCode())
+	gm.sendSnapshot(gameState, player, connectionId)
+	gm.send(gameState.connections[1-seat], fmt.Sprintf("RECONNECTED %s", player.GetPlayerCode()))
+	gm.spectate(gameState, fmt.Sprintf("RECONNECTED %s", player.GetPlayerCode()))
+	return nil
+}
+
+// sendSnapshot tells a resumed player everything they need to carry on: their
+// own fleet and the shots it took, the shots they fired and whose turn it is.
+func (gm *GameManager) sendSnapshot(gameState *GameState, player *game.Player, connectionId int) {
+	thisGame := gameState.game
+	opponent := thisGame.GetOtherPlayer(connectionId)
+
+	gm.send(connectionId, fmt.Sprintf("RESUMED %s %s %s", player.GetPlayerCode(), player.GetName(), rulesMessage(thisGame)))
+	for _, placement := range player.Fleet.Placements() {
+		gm.send(connectionId, fmt.Sprintf("SHIP %s %d %d %s", placement.ShipType, placement.X, placement.Y, placement.Direction))
+	}
+	for _, shot := range player.Fleet.Shots() {
+		gm.send(connectionId, "INCOMING "+shotMessage(shot))
+	}
+	for _, shot := range opponent.Fleet.Shots() {
+		gm.send(connectionId, "SHOT "+shotMessage(shot))
+	}
+	gm.send(connectionId, fmt.Sprintf("STATE %s", player.State))
+	switch player.State {
+	case game.PLAYING:
+		gm.send(connectionId, turnMessage(thisGame, player))
+	case game.WAITING_FOR_ATTACK:
+		gm.send(connectionId, turnMessage(thisGame, opponent))
+	}
+	gm.send(connectionId, "END")
+}
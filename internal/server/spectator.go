@@ -0,0 +1,154 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+func (gm *GameManager) getWatchedGame(connectionId int) *GameState {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+
+	return gm.watching[connectionId]
+}
+
+// spectate sends a message to everyone watching a game.
+func (gm *GameManager) spectate(gameState *GameState, message string) {
+	for _, connectionId := range gameState.spectators {
+		gm.send(connectionId, message)
+	}
+}
+
+// closeMatch takes a game that is over off the list of games that can be
+// watched, sends its spectators back to the lobby and closes its match log.
+// Must be called with the game locked.
+func (gm *GameManager) closeMatch(gameState *GameState) {
+	if gameState.matchLog != nil {
+		gameState.matchLog.Close()
+		gameState.matchLog = nil
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+
+	delete(gm.matches, gameState.id)
+	for _, connectionId := range gameState.spectators {
+		delete(gm.watching, connectionId)
+	}
+	gameState.spectators = nil
+}
+
+// unwatch detaches a spectator. Must be called with the game locked.
+func (gm *GameManager) unwatch(gameState *GameState, connectionId int) {
+	gameState.spectators = slices.DeleteFunc(gameState.spectators, func(id int) bool {
+		return id == connectionId
+	})
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	delete(gm.watching, connectionId)
+}
+
+func (gm *GameManager) handleGamesCommand(connectionId int, _ Command) error {
+	gm.mu.RLock()
+	ids := slices.Sorted(maps.Keys(gm.matches))
+	gm.mu.RUnlock()
+
+	reply := []string{"GAMES"}
+	for _, id := range ids {
+		reply = append(reply, strconv.Itoa(id))
+	}
+	gm.send(connectionId, strings.Join(reply, " "))
+	return nil
+}
+
+// handleWatchCommand attaches a read-only connection to a game. From then on
+// the spectator gets everything that is broadcast to the players.
+func (gm *GameManager) handleWatchCommand(connectionId int, cmd Command) error {
+	gameId := cmd.(WatchCommand).GameId
+
+	gm.mu.RLock()
+	gameState := gm.matches[gameId]
+	gm.mu.RUnlock()
+	if gameState == nil {
+		return fmt.Errorf("%w: %d", errGameNotFound, gameId)
+	}
+
+	gameState.mu.Lock()
+	defer gameState.mu.Unlock()
+
+	gm.mu.Lock()
+	if gm.matches[gameId] != gameState {
+		// the game ended while we were waiting for the lock
+		gm.mu.Unlock()
+		return fmt.Errorf("%w: %d", errGameNotFound, gameId)
+	}
+	gm.watching[connectionId] = gameState
+	gm.mu.Unlock()
+	gameState.spectators = append(gameState.spectators, connectionId)
+
+	log.Printf("[server %d] watching game %d", connectionId, gameId)
+	gm.sendSpectatorSnapshot(gameState, connectionId)
+	return nil
+}
+
+// sendSpectatorSnapshot catches a spectator up on a game: who plays, the
+// shots fired so far and whose turn it is. Ships are only shown in
+// omniscient mode.
+func (gm *GameManager) sendSpectatorSnapshot(gameState *GameState, connectionId int) {
+	thisGame := gameState.game
+
+	gm.send(connectionId, fmt.Sprintf("WATCHING %d %s", gameState.id, rulesMessage(thisGame)))
+	for seat := range gameState.connections {
+		if player := thisGame.PlayerAt(seat); player != nil {
+			gm.send(connectionId, fmt.Sprintf("PLAYER %s %s", player.GetPlayerCode(), player.GetName()))
+		}
+	}
+	for seat := range gameState.connections {
+		if player := thisGame.PlayerAt(seat); player != nil && gm.config.Omniscient {
+			gm.sendFleet(connectionId, player)
+		}
+	}
+	for seat := range gameState.connections {
+		player := thisGame.PlayerAt(seat)
+		target := thisGame.PlayerAt(1 - seat)
+		if player == nil || target == nil {
+			continue
+		}
+		for _, shot := range target.Fleet.Shots() {
+			gm.send(connectionId, fmt.Sprintf("SHOT %s %s", player.GetPlayerCode(), shotMessage(shot)))
+		}
+	}
+	for seat := range gameState.connections {
+		if player := thisGame.PlayerAt(seat); player != nil && player.State == game.PLAYING {
+			gm.send(connectionId, turnMessage(thisGame, player))
+		}
+	}
+	gm.send(connectionId, "END")
+}
+
+// revealFleets shows both fleets to the spectators once the game starts,
+// in omniscient mode. Must be called with the game locked.
+func (gm *GameManager) revealFleets(gameState *GameState) {
+	if !gm.config.Omniscient {
+		return
+	}
+	for _, connectionId := range gameState.spectators {
+		for seat := range gameState.connections {
+			gm.sendFleet(connectionId, gameState.game.PlayerAt(seat))
+		}
+	}
+}
+
+func (gm *GameManager) sendFleet(connectionId int, player *game.Player) {
+	for _, placement := range player.Fleet.Placements() {
+		gm.send(connectionId, fmt.Sprintf("SHIP %s %s %d %d %s",
+			player.GetPlayerCode(), placement.ShipType, placement.X, placement.Y, placement.Direction))
+	}
+}
@@ -0,0 +1,73 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/protocol"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestAbilities(t *testing.T) {
+	s := server.NewServer(":8022")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1 := startConnection(t, ":8022")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8022")
+	defer conn2.Close()
+
+	expectResponse(t, conn1, "CREATE arsenal rules=advanced", "OK CREATE arsenal")
+	expectResponse(t, conn2, "JOIN arsenal", "OK JOIN arsenal")
+	expectWelcome(t, conn1, "Alice", "P1")
+	expectWelcome(t, conn2, "Bob", "P2")
+	expectResponse(t, conn1, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+	expectResponse(t, conn2, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+	sendClientMessage(conn1, "READY")
+	sendClientMessage(conn2, "READY")
+	readUntil(t, conn1, "TURN P1")
+	readUntil(t, conn2, "START P1")
+
+	// the battleship at (6, 7) and the submarine at (9, 9) are in range
+	expectResponse(t, conn1, "SONAR 8 8", "SONAR P1 8 8 FOUND")
+	expectLine(t, conn2, "SONAR P1 8 8 FOUND")
+
+	expectResponse(t, conn1, "TORPEDO DIAG 4", "ERROR INVALID_COMMAND invalid command: TORPEDO <ROW|COL> <n>")
+	expectResponse(t, conn1, "TORPEDO COL 10", "ERROR OUT_OF_BOUNDS coordinate out of bounds: (10, 0)")
+	expectBlock(t, conn1, conn2, "TORPEDO COL 4",
+		"TORPEDO P1 COL 4", "MISS 4 0", "MISS 4 1", "MISS 4 2", "MISS 4 3", "MISS 4 4", "HIT 4 5", "END")
+	expectResponse(t, conn1, "ATTACK 0 0", "MISS 0 0")
+	expectLine(t, conn2, "MISS 0 0")
+	expectLine(t, conn2, "TURN P2")
+
+	// cells shot already are left out of the area
+	expectBlock(t, conn2, conn1, "STRIKE 2 1",
+		"STRIKE P2 2 1", "MISS 1 0", "MISS 2 0", "HIT 3 0", "HIT 1 1", "HIT 2 1", "HIT 3 1",
+		"MISS 1 2", "MISS 2 2", "SUNK 3 2 CARRIER", "END")
+	expectResponse(t, conn2, "STRIKE 6 6", "ERROR ABILITY_UNAVAILABLE ability not available: STRIKE")
+	expectResponse(t, conn2, "ATTACK 0 0", "MISS 0 0")
+	expectResponse(t, conn2, "ATTACK 0 1", "MISS 0 1")
+	readUntil(t, conn1, "TURN P1")
+
+	// P1 never used their strike, but it went down with their carrier
+	expectResponse(t, conn1, "STRIKE 6 6", "ERROR ABILITY_UNAVAILABLE ability not available: STRIKE")
+
+	// one torpedo per battleship
+	expectBlock(t, conn1, conn2, "TORPEDO ROW 7", "TORPEDO P1 ROW 7", "HIT 0 7", "END")
+	expectResponse(t, conn1, "TORPEDO ROW 8", "ERROR ABILITY_UNAVAILABLE ability not available: TORPEDO")
+}
+
+// expectBlock sends message on conn and expects both players to receive the
+// lines in order.
+func expectBlock(t *testing.T, conn *protocol.Conn, other *protocol.Conn, message string, expected ...string) {
+	t.Helper()
+
+	sendClientMessage(conn, message)
+	for _, c := range []*protocol.Conn{conn, other} {
+		if lines := readUntil(t, c, "END"); strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+			t.Fatalf("Expected %q in response to %q, got %q", expected, message, lines)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestAdjacency(t *testing.T) {
+	s := server.NewServer(":8017")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1 := startConnection(t, ":8017")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8017")
+	defer conn2.Close()
+
+	expectMatch(t, sendLine(conn1, "CREATE apart adjacency=sometimes"), `^ERROR INVALID_ADJACENCY `)
+
+	// the russian preset forbids any contact, corners included
+	expectResponse(t, conn1, "CREATE apart rules=russian", "OK CREATE apart")
+	expectMatch(t, sendLine(conn1, "HELLO Alice"), `^WELCOME P1 Alice \w+ size=10 rules=russian adjacency=no-contact repeat=reject turns=fixed$`)
+	expectResponse(t, conn1, "SHIP BATTLESHIP 0 0 H", "OK SHIP BATTLESHIP")
+	expectResponse(t, conn1, "SHIP SUBMARINE 4 1 H", "ERROR ADJACENT ships may not touch: (4, 1) touches (3, 0)")
+	expectResponse(t, conn1, "SHIP SUBMARINE 5 1 H", "OK SHIP SUBMARINE")
+
+	// any ruleset can be played without edge contact
+	expectResponse(t, conn2, "CREATE corners adjacency=no-edges", "OK CREATE corners")
+	expectMatch(t, sendLine(conn2, "HELLO Bob"), `^WELCOME P1 Bob \w+ size=10 rules=standard adjacency=no-edges repeat=reject turns=fixed$`)
+	expectResponse(t, conn2, "SHIP SUBMARINE 0 0 H", "OK SHIP SUBMARINE")
+	expectResponse(t, conn2, "SHIP SUBMARINE 1 1 H", "OK SHIP SUBMARINE")
+	expectResponse(t, conn2, "SHIP SUBMARINE 2 1 H", "ERROR ADJACENT ships may not touch: (2, 1) touches (1, 1)")
+	expectResponse(t, conn2, "SHIP DESTROYER 1 0 V", "ERROR OVERLAP position already occupied: (1, 1)")
+}
@@ -0,0 +1,157 @@
+package server_test
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/ai"
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/protocol"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestAI(t *testing.T) {
+	s := server.NewServer(":8011")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	for _, level := range []string{"RANDOM", "HUNT", "DENSITY"} {
+		t.Run(level, func(t *testing.T) {
+			conn := startConnection(t, ":8011")
+			defer conn.Close()
+
+			expectMatch(t, sendLine(conn, "HELLO Human VS_AI "+level), `^WELCOME P1 Human \w+ size=10 rules=standard adjacency=allowed repeat=reject turns=fixed$`)
+			if err := sendFleetMessages(conn, "P1"); err != nil {
+				t.Fatal(err)
+			}
+			playAgainstAI(t, conn, game.TURN_MAX_ATTACKS)
+		})
+	}
+
+	t.Run("single", func(t *testing.T) {
+		conn := startConnection(t, ":8011")
+		defer conn.Close()
+
+		expectMatch(t, sendLine(conn, "HELLO Human VS_AI HUNT turns=single"), `^WELCOME P1 Human \w+ size=10 rules=standard adjacency=allowed repeat=reject turns=single$`)
+		if err := sendFleetMessages(conn, "P1"); err != nil {
+			t.Fatal(err)
+		}
+		playAgainstAI(t, conn, 1)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		conn := startConnection(t, ":8011")
+		defer conn.Close()
+
+		expectMatch(t, sendLine(conn, "HELLO Human VS_AI GODLIKE"), `^ERROR INVALID_LEVEL `)
+		expectMatch(t, sendLine(conn, "HELLO Human VS_HUMAN RANDOM"), `^ERROR INVALID_COMMAND `)
+		expectResponse(t, conn, "CREATE airoom", "OK CREATE airoom")
+		expectMatch(t, sendLine(conn, "HELLO Human VS_AI RANDOM"), `^ERROR UNEXPECTED_COMMAND `)
+	})
+}
+
+// TestAIRejectedShots plays the server side for a bot whose shots are all
+// turned down: it must aim elsewhere every time, then resign instead of
+// waiting for a turn that never comes.
+func TestAIRejectedShots(t *testing.T) {
+	for _, turns := range []struct{ policy, turn, shot string }{
+		{"fixed", "TURN P2", `^ATTACK \d+ \d+$`},
+		{"salvo", "TURN P2 shots=3", `^SALVO( \d+ \d+){3}$`},
+	} {
+		t.Run(turns.policy, func(t *testing.T) {
+			serverSide, botSide := net.Pipe()
+			conn := protocol.NewConn(serverSide)
+			defer conn.Close()
+
+			played := make(chan error, 1)
+			go func() {
+				played <- ai.NewBot("AI_DENSITY", ai.DENSITY, game.Rulesets).Play(botSide)
+			}()
+
+			expectMatch(t, conn, `^HELLO AI_DENSITY$`)
+			if err := conn.WriteLine("WELCOME P2 AI_DENSITY token size=10 rules=standard adjacency=allowed repeat=reject turns=" + turns.policy); err != nil {
+				t.Fatal(err)
+			}
+			for {
+				line, err := readResponse(conn)
+				if err != nil {
+					t.Fatalf("Error reading the fleet: %v", err)
+				}
+				if line == "READY" {
+					break
+				}
+				if err := conn.WriteLine("OK " + line); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := conn.WriteLine(turns.turn); err != nil {
+				t.Fatal(err)
+			}
+			fired := make(map[string]bool)
+			for range 3 {
+				line, err := readResponse(conn)
+				if err != nil {
+					t.Fatalf("Error reading a shot: %v", err)
+				}
+				if !regexp.MustCompile(turns.shot).MatchString(line) || fired[line] {
+					t.Fatalf("Expected a new shot matching %q, got %q", turns.shot, line)
+				}
+				fired[line] = true
+				if err := conn.WriteLine("ERROR ALREADY_SHOT cell already shot"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expectMatch(t, conn, `^QUIT$`)
+			if err := <-played; err == nil || !strings.Contains(err.Error(), "resigned") {
+				t.Fatalf("Expected the bot to resign, got %v", err)
+			}
+		})
+	}
+}
+
+func sendLine(conn *protocol.Conn, message string) *protocol.Conn {
+	sendClientMessage(conn, message)
+	return conn
+}
+
+// playAgainstAI sweeps the board cell by cell, shots at a time, until either
+// side wins.
+func playAgainstAI(t *testing.T, conn *protocol.Conn, shots int) {
+	t.Helper()
+
+	next := 0
+	for {
+		line, err := readResponse(conn)
+		if err != nil {
+			t.Fatalf("Connection closed before the game ended: %v", err)
+		}
+		if strings.HasPrefix(line, "WIN ") {
+			expectClosed(t, conn)
+			return
+		}
+		if line != "TURN P1" {
+			continue
+		}
+
+		for range shots {
+			x, y := next/game.DEFAULT_BOARD_SIZE, next%game.DEFAULT_BOARD_SIZE
+			next++
+			line, err := readResponse(sendLine(conn, fmt.Sprintf("ATTACK %d %d", x, y)))
+			if err != nil {
+				t.Fatalf("Error reading shot result: %v", err)
+			}
+			if line == "WIN P1" {
+				expectClosed(t, conn)
+				return
+			}
+			if !regexp.MustCompile(fmt.Sprintf(`^((HIT|MISS) %d %d|SUNK %d %d \w+)$`, x, y, x, y)).MatchString(line) {
+				t.Fatalf("Expected the result of ATTACK %d %d, got %q", x, y, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestBoardSize(t *testing.T) {
+	s := server.NewServer(":8014")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1 := startConnection(t, ":8014")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8014")
+	defer conn2.Close()
+	conn3 := startConnection(t, ":8014")
+	defer conn3.Close()
+
+	expectMatch(t, sendLine(conn1, "CREATE tiny size=7"), `^ERROR INVALID_BOARD_SIZE `)
+	expectMatch(t, sendLine(conn1, "CREATE tiny size=27"), `^ERROR INVALID_BOARD_SIZE `)
+	expectMatch(t, sendLine(conn1, "CREATE tiny colour=red"), `^ERROR INVALID_COMMAND `)
+
+	// the size is picked by whoever creates the game
+	expectResponse(t, conn1, "CREATE small size=8", "OK CREATE small")
+	expectResponse(t, conn2, "JOIN small", "OK JOIN small")
+	expectMatch(t, sendLine(conn1, "HELLO Alice"), `^WELCOME P1 Alice \w+ size=8 rules=standard adjacency=allowed repeat=reject turns=fixed$`)
+	expectMatch(t, sendLine(conn2, "HELLO Bob size=12"), `^ERROR UNEXPECTED_COMMAND `)
+	expectMatch(t, sendLine(conn2, "HELLO Bob"), `^WELCOME P2 Bob \w+ size=8 rules=standard adjacency=allowed repeat=reject turns=fixed$`)
+	expectMatch(t, sendLine(conn1, "SHIP SUBMARINE 8 8 H"), `^ERROR OUT_OF_BOUNDS `)
+	expectResponse(t, conn1, "SHIP SUBMARINE 7 7 H", "OK SHIP SUBMARINE")
+
+	// queued players are only paired with others asking for the same size
+	conn4 := startConnection(t, ":8014")
+	defer conn4.Close()
+	expectResponse(t, conn3, "QUEUE size=15", "OK QUEUE")
+	expectMatch(t, sendLine(conn4, "HELLO Dave"), `^WELCOME P1 Dave \w+ size=10 rules=standard adjacency=allowed repeat=reject turns=fixed$`)
+	expectMatch(t, sendLine(conn3, "HELLO Carol"), `^WELCOME P1 Carol \w+ size=15 rules=standard adjacency=allowed repeat=reject turns=fixed$`)
+}
@@ -0,0 +1,83 @@
+package server_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/protocol"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestSetupTimeout(t *testing.T) {
+	config := server.DefaultConfig()
+	config.TimeControl.SetupTimeout = 100 * time.Millisecond
+	s := server.NewServerWithConfig(":8008", config)
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1 := startConnection(t, ":8008")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8008")
+	defer conn2.Close()
+
+	expectResponse(t, conn1, "CREATE slow", "OK CREATE slow")
+	expectResponse(t, conn2, "JOIN slow", "OK JOIN slow")
+	expectWelcome(t, conn1, "Alice", "P1")
+	expectWelcome(t, conn2, "Bob", "P2")
+
+	// nobody places a fleet, whoever times out first loses
+	expectMatch(t, conn1, `^WIN P[12] TIMEOUT$`)
+	expectMatch(t, conn2, `^WIN P[12] TIMEOUT$`)
+	expectClosed(t, conn2)
+}
+
+func TestAttackTimeoutRandomShot(t *testing.T) {
+	config := server.DefaultConfig()
+	config.TimeControl.AttackTimeout = 50 * time.Millisecond
+	config.TimeControl.OnTimeout = game.TIMEOUT_RANDOM_SHOT
+	s := server.NewServerWithConfig(":8009", config)
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1, conn2, _ := startGame(t, ":8009", "idle")
+	defer conn1.Close()
+	defer conn2.Close()
+
+	// P1 sits idle, the server shoots for them until the turn passes
+	for range game.TURN_MAX_ATTACKS {
+		expectMatch(t, conn2, `^(HIT|MISS|SUNK) \d \d`)
+	}
+	expectLine(t, conn2, "TURN P2 attack=50")
+}
+
+func TestBankTimeout(t *testing.T) {
+	config := server.DefaultConfig()
+	config.TimeControl.Bank = 100 * time.Millisecond
+	s := server.NewServerWithConfig(":8010", config)
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1, conn2, _ := startGame(t, ":8010", "broke")
+	defer conn1.Close()
+	defer conn2.Close()
+
+	expectResponse(t, conn1, "ATTACK 0 0", "MISS 0 0")
+	expectLine(t, conn1, "WIN P2 TIMEOUT")
+	expectLine(t, conn2, "MISS 0 0")
+	expectLine(t, conn2, "WIN P2 TIMEOUT")
+}
+
+func expectMatch(t *testing.T, conn *protocol.Conn, pattern string) {
+	t.Helper()
+
+	line, err := readResponse(conn)
+	if err != nil {
+		t.Fatalf("Error reading line: %v", err)
+	}
+	if !regexp.MustCompile(pattern).MatchString(line) {
+		t.Fatalf("Expected a line matching %q, got %q", pattern, strings.TrimSpace(line))
+	}
+}
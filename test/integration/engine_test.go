@@ -0,0 +1,114 @@
+package server_test
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+)
+
+// standardPlacements reads STANDARD_FLEET into placements for the engine.
+func standardPlacements() []game.Placement {
+	var placements []game.Placement
+	for _, ship := range STANDARD_FLEET {
+		fields := strings.Fields(ship)
+		x, _ := strconv.Atoi(fields[1])
+		y, _ := strconv.Atoi(fields[2])
+		placements = append(placements, game.Placement{ShipType: game.ShipType(fields[0]), X: x, Y: y, Direction: fields[3]})
+	}
+	return placements
+}
+
+func apply(t *testing.T, g *game.Game, cmd game.Command) []game.Event {
+	t.Helper()
+	events, err := g.Apply(cmd)
+	if err != nil {
+		t.Fatalf("%T: %v", cmd, err)
+	}
+	return events
+}
+
+// TestEngine plays a whole match through game.Apply, without a server.
+func TestEngine(t *testing.T) {
+	g := game.NewGame()
+
+	apply(t, g, game.Join{Seat: 0, Id: 1, Name: "alice"})
+	if _, err := g.Apply(game.Ready{Seat: 1}); !errors.Is(err, game.ErrNoPlayer) {
+		t.Fatalf("Expected %v before P2 joined, got %v", game.ErrNoPlayer, err)
+	}
+	apply(t, g, game.Join{Seat: 1, Id: 2, Name: "bob"})
+	if _, err := g.Apply(game.Join{Seat: 1, Id: 3, Name: "carol"}); !errors.Is(err, game.ErrWrongPhase) {
+		t.Fatalf("Expected %v for a taken seat, got %v", game.ErrWrongPhase, err)
+	}
+
+	for seat := range 2 {
+		apply(t, g, game.PlaceFleet{Seat: seat, Placements: standardPlacements()})
+	}
+	if events := apply(t, g, game.Ready{Seat: 0}); !reflect.DeepEqual(events, []game.Event{game.PlayerReady{Seat: 0}}) {
+		t.Fatalf("Expected P1 ready alone, got %v", events)
+	}
+	expected := []game.Event{game.PlayerReady{Seat: 1}, game.GameStarted{}, game.TurnStarted{Seat: 0}}
+	if events := apply(t, g, game.Ready{Seat: 1}); !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Expected %v, got %v", expected, events)
+	}
+	if _, err := g.Apply(game.PlaceShip{Seat: 0, Placement: standardPlacements()[0]}); !errors.Is(err, game.ErrWrongPhase) {
+		t.Fatalf("Expected %v after the start, got %v", game.ErrWrongPhase, err)
+	}
+	if _, err := g.Apply(game.Attack{Seat: 1, Target: game.Vector2{}}); !errors.Is(err, game.ErrNotYourTurn) {
+		t.Fatalf("Expected %v, got %v", game.ErrNotYourTurn, err)
+	}
+
+	// both players sweep the board from the top left, P1 gets there first
+	var next [2]int
+	var last game.Event
+	for !g.IsOver() {
+		seat := g.CurrentSeat()
+		target := game.Vector2{X: next[seat] % g.BoardSize, Y: next[seat] / g.BoardSize}
+		next[seat]++
+		events := apply(t, g, game.Attack{Seat: seat, Target: target})
+		if shot := events[0].(game.ShotFired); shot.Seat != seat || shot.Shot.Position != target {
+			t.Fatalf("Expected a shot by seat %d at %v, got %v", seat, target, shot)
+		}
+		last = events[len(events)-1]
+	}
+
+	if last != (game.GameWon{Seat: 0}) {
+		t.Fatalf("Expected P1 to win, got %v", last)
+	}
+	if g.PlayerAt(0).State != game.WON || g.PlayerAt(1).State != game.LOST {
+		t.Fatalf("Expected WON and LOST, got %v and %v", g.PlayerAt(0).State, g.PlayerAt(1).State)
+	}
+	if _, err := g.Apply(game.Forfeit{Seat: 0, Reason: "QUIT"}); !errors.Is(err, game.ErrWrongPhase) {
+		t.Fatalf("Expected %v once the game is over, got %v", game.ErrWrongPhase, err)
+	}
+}
+
+// TestEngineTorpedoDirection checks that a torpedo only runs along a row or
+// a column, and that a rejected one leaves the ability unused.
+func TestEngineTorpedoDirection(t *testing.T) {
+	g := game.NewGame()
+	g.Rules = game.Rulesets["advanced"]
+	apply(t, g, game.Join{Seat: 0, Id: 1, Name: "alice"})
+	apply(t, g, game.Join{Seat: 1, Id: 2, Name: "bob"})
+	for seat := range 2 {
+		apply(t, g, game.PlaceFleet{Seat: seat, Placements: standardPlacements()})
+		apply(t, g, game.Ready{Seat: seat})
+	}
+
+	for _, direction := range []game.Vector2{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 2}} {
+		if _, err := g.Apply(game.Torpedo{Seat: 0, Start: game.Vector2{}, Direction: direction}); !errors.Is(err, game.ErrInvalidDirection) {
+			t.Fatalf("Expected %v for direction %v, got %v", game.ErrInvalidDirection, direction, err)
+		}
+	}
+
+	// both torpedoes are still there
+	for range 2 {
+		events := apply(t, g, game.Torpedo{Seat: 0, Start: game.Vector2{X: 4, Y: 0}, Direction: game.Vector2{X: 0, Y: 1}})
+		if _, ok := events[0].(game.TorpedoFired); !ok {
+			t.Fatalf("Expected a torpedo, got %v", events)
+		}
+	}
+}
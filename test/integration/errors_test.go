@@ -0,0 +1,35 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestMalformedInput(t *testing.T) {
+	s := server.NewServer(":8005")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn := startConnection(t, ":8005")
+	defer conn.Close()
+
+	expectResponse(t, conn, "CREATE sandbox extra", "ERROR INVALID_COMMAND invalid command: expected <option>=<value>, got extra")
+	expectResponse(t, conn, "FIRE 1 1", "ERROR UNKNOWN_COMMAND unknown command: FIRE")
+	expectResponse(t, conn, "CREATE sandbox", "OK CREATE sandbox")
+	expectResponse(t, conn, "ATTACK 1 1", "ERROR UNEXPECTED_COMMAND unexpected command: ATTACK")
+	expectResponse(t, conn, "HELLO", "ERROR INVALID_COMMAND invalid command: HELLO <name> [VS_AI <level>] [<option>=<value>...]")
+	expectWelcome(t, conn, "Sandboxer", "P1")
+
+	expectResponse(t, conn, "SHIP CARRIER a 1 H", `ERROR INVALID_COORDINATE invalid coordinate: "a"`)
+	expectResponse(t, conn, "SHIP CARRIER 8 1 H", "ERROR OUT_OF_BOUNDS coordinate out of bounds: (10, 1)")
+	expectResponse(t, conn, "SHIP CANOE 1 1 H", "ERROR INVALID_SHIP_TYPE invalid ship type: CANOE")
+	expectResponse(t, conn, "SHIP CRUISER 1 1 D", "ERROR INVALID_DIRECTION invalid direction: D")
+	expectResponse(t, conn, "SHIP CRUISER 1 1 H", "OK SHIP CRUISER")
+	expectResponse(t, conn, "SHIP SUBMARINE 2 1 H", "ERROR OVERLAP position already occupied: (2, 1)")
+	expectResponse(t, conn, "ATTACK 1 1", "ERROR UNEXPECTED_COMMAND unexpected command: ATTACK")
+	expectResponse(t, conn, "READY", "ERROR FLEET_INCOMPLETE fleet not complete: 0 of 1 CARRIER placed")
+	expectResponse(t, conn, "LIST", "ERROR UNEXPECTED_COMMAND unexpected command: LIST")
+	expectResponse(t, conn, "QUIT", "BYE")
+}
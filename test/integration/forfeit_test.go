@@ -0,0 +1,91 @@
+package server_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/protocol"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestForfeit(t *testing.T) {
+	config := server.DefaultConfig()
+	config.ResumeGrace = 100 * time.Millisecond
+	s := server.NewServerWithConfig(":8007", config)
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	t.Run("quit", func(t *testing.T) {
+		conn1, conn2, _ := startGame(t, ":8007", "quitters")
+		defer conn1.Close()
+		defer conn2.Close()
+
+		expectResponse(t, conn1, "QUIT", "BYE")
+		expectLine(t, conn1, "WIN P2 FORFEIT")
+		expectLine(t, conn2, "WIN P2 FORFEIT")
+		expectClosed(t, conn2)
+	})
+
+	t.Run("grace expired", func(t *testing.T) {
+		conn1, conn2, _ := startGame(t, ":8007", "droppers")
+		defer conn2.Close()
+
+		conn1.Close()
+		expectLine(t, conn2, "DISCONNECTED P1")
+		expectLine(t, conn2, "WIN P2 FORFEIT")
+		expectClosed(t, conn2)
+	})
+
+	t.Run("abandoned", func(t *testing.T) {
+		conn1 := startConnection(t, ":8007")
+		conn2 := startConnection(t, ":8007")
+		defer conn2.Close()
+
+		expectResponse(t, conn1, "CREATE ghosts", "OK CREATE ghosts")
+		expectWelcome(t, conn1, "Casper", "P1")
+		expectResponse(t, conn2, "JOIN ghosts", "OK JOIN ghosts")
+
+		// P2 never said HELLO, so there is nothing to forfeit
+		conn1.Close()
+		expectLine(t, conn2, "ABANDONED")
+		expectResponse(t, conn2, "LIST", "ROOMS")
+	})
+}
+
+// startGame pairs two new connections in a room, places both fleets and
+// waits for P1's first TURN. It also returns P1's session token.
+func startGame(t *testing.T, address string, room string) (*protocol.Conn, *protocol.Conn, string) {
+	t.Helper()
+
+	conn1 := startConnection(t, address)
+	conn2 := startConnection(t, address)
+
+	expectResponse(t, conn1, "CREATE "+room, "OK CREATE "+room)
+	expectResponse(t, conn2, "JOIN "+room, "OK JOIN "+room)
+	token := expectWelcome(t, conn1, "Alice", "P1")
+	expectWelcome(t, conn2, "Bob", "P2")
+
+	errChan := make(chan error, 2)
+	go func() { errChan <- sendFleetMessages(conn1, "P1") }()
+	go func() { errChan <- sendFleetMessages(conn2, "P2") }()
+	for range 2 {
+		if err := <-errChan; err != nil {
+			t.Fatalf("Error placing fleet: %v", err)
+		}
+	}
+
+	if line, _ := readResponse(conn1); !strings.HasPrefix(line, "TURN P1") {
+		t.Fatalf("Expected TURN P1, got %q", line)
+	}
+	return conn1, conn2, token
+}
+
+func expectClosed(t *testing.T, conn *protocol.Conn) {
+	t.Helper()
+
+	if line, err := conn.ReadLine(); err != io.EOF {
+		t.Fatalf("Expected connection to be closed, got %q, %v", line, err)
+	}
+}
@@ -0,0 +1,119 @@
+package server_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/protocol"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestLobby(t *testing.T) {
+	s := server.NewServer(":8002")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	// a client that drops before saying anything must not affect pairing
+	dropped := startConnection(t, ":8002")
+	dropped.Close()
+
+	conn1 := startConnection(t, ":8002")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8002")
+	defer conn2.Close()
+
+	expectResponse(t, conn1, "LIST", "ROOMS")
+	expectResponse(t, conn1, "CREATE arena", "OK CREATE arena")
+	expectResponse(t, conn2, "CREATE arena", "ERROR ROOM_EXISTS room already exists: arena")
+	expectResponse(t, conn2, "LIST", "ROOMS arena")
+	expectResponse(t, conn2, "JOIN nowhere", "ERROR ROOM_NOT_FOUND room not found: nowhere")
+	expectResponse(t, conn2, "JOIN arena", "OK JOIN arena")
+
+	errChan := make(chan error, 2)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errChan <- doClientStuff(conn1, "P1", "Host")
+	}()
+	time.Sleep(100 * time.Millisecond)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errChan <- doClientStuff(conn2, "P2", "Guest")
+	}()
+	wg.Wait()
+	close(errChan)
+
+	for err := range errChan {
+		if err != nil {
+			t.Errorf("Error in client: %v", err)
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	s := server.NewServer(":8003")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1 := startConnection(t, ":8003")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8003")
+	defer conn2.Close()
+
+	// the second client queues first, so it becomes P1
+	expectResponse(t, conn2, "QUEUE", "OK QUEUE")
+	expectResponse(t, conn1, "QUEUE", "OK QUEUE")
+	expectWelcome(t, conn2, "First", "P1")
+	expectWelcome(t, conn1, "Second", "P2")
+}
+
+func expectResponse(t *testing.T, conn *protocol.Conn, message string, expected string) {
+	t.Helper()
+
+	if err := exchange(conn, message, expected); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exchange is expectResponse for goroutines other than the test's, which
+// may not stop the test.
+func exchange(conn *protocol.Conn, message string, expected string) error {
+	if err := sendClientMessage(conn, message); err != nil {
+		return fmt.Errorf("error sending %q: %w", message, err)
+	}
+	response, err := readResponse(conn)
+	if err != nil {
+		return fmt.Errorf("error reading response to %q: %w", message, err)
+	}
+	if response != expected {
+		return fmt.Errorf("expected %q in response to %q, got %q", expected, message, response)
+	}
+	return nil
+}
+
+// TestQueuePartnerLeaves pairs a HELLO from the lobby with a player who
+// drops at the same moment. The HELLO must still be answered, by a WELCOME
+// into the paired game or into a new one.
+func TestQueuePartnerLeaves(t *testing.T) {
+	s := server.NewServer(":8024")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	for range 500 {
+		waiting := startConnection(t, ":8024")
+		expectResponse(t, waiting, "QUEUE", "OK QUEUE")
+
+		conn := startConnection(t, ":8024")
+		go waiting.Close()
+		expectMatch(t, sendLine(conn, "HELLO Bob"), `^WELCOME P[12] Bob `)
+		conn.Close()
+	}
+
+	conn := startConnection(t, ":8024")
+	defer conn.Close()
+	expectResponse(t, conn, "LIST", "ROOMS")
+}
@@ -0,0 +1,83 @@
+package server_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/matchlog"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestMatchLog(t *testing.T) {
+	config := server.DefaultConfig()
+	config.MatchLogDir = t.TempDir()
+	s := server.NewServerWithConfig(":8023", config)
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1, conn2, _ := startGame(t, ":8023", "recorded")
+	defer conn1.Close()
+	defer conn2.Close()
+
+	expectResponse(t, conn1, "ATTACK 9 2", "SUNK 9 2 SUBMARINE")
+	expectResponse(t, conn1, "ATTACK 9 2", "ERROR ALREADY_SHOT cell already attacked: (9, 2)")
+	expectResponse(t, conn1, "ATTACK 0 0", "MISS 0 0")
+	expectResponse(t, conn1, "ATTACK 1 1", "HIT 1 1")
+	readUntil(t, conn2, "TURN P2")
+	expectResponse(t, conn2, "QUIT", "BYE")
+	expectLine(t, conn1, "WIN P1 FORFEIT")
+	expectClosed(t, conn1)
+
+	paths, _ := filepath.Glob(filepath.Join(config.MatchLogDir, "match-*.jsonl"))
+	if len(paths) != 1 {
+		t.Fatalf("Expected one match log, got %v", paths)
+	}
+	replay, err := matchlog.Open(paths[0])
+	if err != nil {
+		t.Fatalf("Error reading match log: %v", err)
+	}
+
+	if replay.Setup != (matchlog.Setup{BoardSize: 10, Rules: "standard", Adjacency: "allowed", Repeat: "reject", Turns: "fixed"}) {
+		t.Fatalf("Unexpected setup %+v", replay.Setup)
+	}
+	// two joins, 22 ships, two readies, three shots and the forfeit, the
+	// rejected shot is not logged
+	if len(replay.Steps) != 30 {
+		t.Fatalf("Expected 30 steps, got %d", len(replay.Steps))
+	}
+	last := replay.Steps[len(replay.Steps)-1]
+	if last.Command != (game.Forfeit{Seat: 1, Reason: "FORFEIT"}) || last.Player != "P2" || last.Turn != 2 {
+		t.Fatalf("Unexpected last step %+v", last)
+	}
+
+	final, err := replay.GameAt(len(replay.Steps))
+	if err != nil {
+		t.Fatalf("Error replaying: %v", err)
+	}
+	if final.PlayerAt(0).State != game.WON || final.PlayerAt(1).State != game.LOST {
+		t.Fatalf("Expected P1 to win, got %v and %v", final.PlayerAt(0).State, final.PlayerAt(1).State)
+	}
+
+	// P2's turn, before they quit
+	turn := replay.StepAtTurn(2)
+	middle, err := replay.GameAt(turn)
+	if err != nil {
+		t.Fatalf("Error replaying: %v", err)
+	}
+	if middle.IsOver() || middle.TurnCount != 2 || middle.CurrentSeat() != 1 {
+		t.Fatalf("Expected P2's turn at step %d, got turn %d", turn, middle.TurnCount)
+	}
+	grid := middle.PlayerAt(1).Fleet.ShotGrid()
+	if grid[2][9] != game.CELL_SUNK || grid[0][0] != game.CELL_MISS || grid[1][1] != game.CELL_HIT {
+		t.Fatalf("Unexpected shots on P2's board: %v", grid[:3])
+	}
+
+	// a log that does not follow from the rules is caught
+	replay.Steps[turn-2].Events[0] = game.ShotFired{Seat: 0, Shot: game.Shot{Position: game.Vector2{X: 0, Y: 0}, Hit: true}}
+	if _, err := replay.GameAt(len(replay.Steps)); !errors.Is(err, matchlog.ErrDiverged) {
+		t.Fatalf("Expected %v, got %v", matchlog.ErrDiverged, err)
+	}
+}
@@ -0,0 +1,125 @@
+package server_test
+
+import (
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+// STANDARD_FLEET is a legal fleet for the standard ruleset, as sent in FLEET.
+var STANDARD_FLEET = []string{
+	"CARRIER 1 1 H",
+	"CRUISER 5 0 V",
+	"BATTLESHIP 6 7 H",
+	"BATTLESHIP 0 7 H",
+	"DESTROYER 4 5 H",
+	"DESTROYER 0 3 V",
+	"DESTROYER 7 0 H",
+	"SUBMARINE 9 2 V",
+	"SUBMARINE 9 4 V",
+	"SUBMARINE 9 9 H",
+	"SUBMARINE 0 9 H",
+}
+
+func TestPlacement(t *testing.T) {
+	s := server.NewServer(":8018")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1 := startConnection(t, ":8018")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8018")
+	defer conn2.Close()
+
+	expectResponse(t, conn1, "CREATE layout", "OK CREATE layout")
+	expectResponse(t, conn2, "JOIN layout", "OK JOIN layout")
+	expectWelcome(t, conn1, "Alice", "P1")
+	expectWelcome(t, conn2, "Bob", "P2")
+
+	// a rejected ship does not count against the ruleset
+	expectResponse(t, conn1, "SHIP CARRIER 0 1 H", "OK SHIP CARRIER")
+	expectResponse(t, conn1, "SHIP SUBMARINE 1 1 H", "ERROR OVERLAP position already occupied: (1, 1)")
+	expectResponse(t, conn1, "UNSHIP 9 9", "ERROR NO_SHIP no ship there: (9, 9)")
+	expectResponse(t, conn1, "UNSHIP 2 0", "OK UNSHIP CARRIER")
+	expectResponse(t, conn1, "SHIP CARRIER 4 4 V", "OK SHIP CARRIER")
+	expectResponse(t, conn1, "RESET", "OK RESET")
+	expectResponse(t, conn1, "READY", "ERROR FLEET_INCOMPLETE fleet not complete: 0 of 1 CARRIER placed")
+
+	// FLEET is all or nothing
+	expectResponse(t, conn1, "FLEET CARRIER 1 1", "ERROR INVALID_COMMAND invalid command: FLEET <type> <x> <y> <direction> [<type> <x> <y> <direction>...]")
+	expectResponse(t, conn1, "FLEET CARRIER 1 1 H CRUISER 1 0 V",
+		"ERROR OVERLAP ship 2: position already occupied: (1, 1)")
+	expectResponse(t, conn1, "FLEET "+strings.Join(STANDARD_FLEET[:10], " "),
+		"ERROR FLEET_INCOMPLETE fleet not complete: 3 of 4 SUBMARINE placed")
+	expectResponse(t, conn1, "UNSHIP 1 1", "ERROR NO_SHIP no ship there: (1, 1)")
+
+	expectResponse(t, conn1, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+	expectResponse(t, conn2, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+	sendClientMessage(conn1, "READY")
+	sendClientMessage(conn2, "READY")
+	expectLine(t, conn1, "START P1")
+	expectLine(t, conn2, "START P1")
+	expectLine(t, conn1, "TURN P1")
+	expectResponse(t, conn1, "ATTACK 1 1", "HIT 1 1")
+	expectResponse(t, conn1, "RESET", "ERROR UNEXPECTED_COMMAND unexpected command: RESET")
+}
+
+const CROWDED_RULESETS = `{
+  "rulesets": [
+    {
+      "name": "crowded",
+      "attacksPerTurn": 1,
+      "adjacency": "ADJACENCY",
+      "ships": [
+        { "type": "LONG", "count": 10, "shape": [[0, 0], [1, 0], [2, 0], [3, 0]] }
+      ]
+    }
+  ]
+}`
+
+// TestFleetTooLarge checks that a fleet that cannot fit is turned down,
+// instead of being placed at random forever.
+func TestFleetTooLarge(t *testing.T) {
+	// ten 4x1 ships that may not touch need 100 cells of a 9x9 board
+	noContact := strings.Replace(CROWDED_RULESETS, "ADJACENCY", "no-contact", 1)
+	if _, err := game.ReadRulesets(strings.NewReader(noContact)); !errors.Is(err, game.ErrInvalidRuleset) {
+		t.Fatalf("Expected %v, got %v", game.ErrInvalidRuleset, err)
+	}
+
+	allowed := strings.Replace(CROWDED_RULESETS, "ADJACENCY", "allowed", 1)
+	crowded, err := game.ReadRulesets(strings.NewReader(allowed))
+	if err != nil {
+		t.Fatalf("Error reading rulesets: %v", err)
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	rules := crowded[0]
+	if placements, err := game.RandomPlacements(rng, rules, game.MAX_BOARD_SIZE); err != nil || len(placements) != 10 {
+		t.Fatalf("Expected 10 placements, got %v, %v", placements, err)
+	}
+	rules = rules.WithAdjacency(game.ADJACENCY_NO_CONTACT)
+	if _, err := game.RandomPlacements(rng, rules, game.MIN_BOARD_SIZE); !errors.Is(err, game.ErrFleetTooLarge) {
+		t.Fatalf("Expected %v, got %v", game.ErrFleetTooLarge, err)
+	}
+
+	// the options can ask for the same, and are turned down before there
+	// is a game
+	config := server.DefaultConfig()
+	config.Rulesets = game.Rulesets.With(crowded...)
+	s := server.NewServerWithConfig(":8025", config)
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn := startConnection(t, ":8025")
+	defer conn.Close()
+
+	expectMatch(t, sendLine(conn, "CREATE packed rules=crowded size=8 adjacency=no-contact"), `^ERROR FLEET_TOO_LARGE `)
+	expectMatch(t, sendLine(conn, "QUEUE rules=crowded size=8 adjacency=no-contact"), `^ERROR FLEET_TOO_LARGE `)
+	expectMatch(t, sendLine(conn, "HELLO Alice VS_AI RANDOM rules=crowded size=8 adjacency=no-contact"), `^ERROR FLEET_TOO_LARGE `)
+	expectResponse(t, conn, "LIST", "ROOMS")
+	expectResponse(t, conn, "CREATE packed rules=crowded size=9 adjacency=no-contact", "OK CREATE packed")
+}
@@ -0,0 +1,78 @@
+package server_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/protocol"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestFraming(t *testing.T) {
+	s := server.NewServer(":8004")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	raw, err := net.Dial("tcp", ":8004")
+	if err != nil {
+		t.Fatalf("Failed to connect to server: %v", err)
+	}
+	defer raw.Close()
+	conn := protocol.NewConn(raw)
+
+	// a command split across two writes
+	raw.Write([]byte("LI"))
+	time.Sleep(10 * time.Millisecond)
+	raw.Write([]byte("ST\r\n"))
+	expectLine(t, conn, "ROOMS")
+
+	// an overlong line is rejected without dropping the session
+	raw.Write([]byte(strings.Repeat("X", protocol.MaxLineLength+10) + "\n"))
+	expectLine(t, conn, "ERROR LINE_TOO_LONG line too long")
+
+	// two commands coalesced in a single write
+	raw.Write([]byte("CREATE framed\nHELLO Framer\n"))
+	expectLine(t, conn, "OK CREATE framed")
+	if line, _ := conn.ReadLine(); !strings.HasPrefix(line, "WELCOME P1 Framer ") {
+		t.Fatalf("Expected WELCOME, got %q", line)
+	}
+
+	// the longest FLEET a ruleset allows is read, and turned down as a
+	// command rather than as a line
+	ship := " " + strings.Repeat("S", game.MAX_SHIP_TYPE_LENGTH) + " 25 25 270M"
+	fleet := "FLEET" + strings.Repeat(ship, game.MAX_BOARD_SIZE*game.MAX_BOARD_SIZE)
+	if len(fleet) > protocol.MaxLineLength {
+		t.Fatalf("A FLEET of %d bytes does not fit a line of %d", len(fleet), protocol.MaxLineLength)
+	}
+	if err := conn.WriteLine(fleet); err != nil {
+		t.Fatalf("Error sending the FLEET: %v", err)
+	}
+	if line, _ := conn.ReadLine(); !strings.HasPrefix(line, "ERROR ") || strings.HasPrefix(line, "ERROR LINE_TOO_LONG ") {
+		t.Fatalf("Expected the FLEET to be read and rejected, got %q", line)
+	}
+
+	// a final line without its newline still counts
+	last, err := net.Dial("tcp", ":8004")
+	if err != nil {
+		t.Fatalf("Failed to connect to server: %v", err)
+	}
+	defer last.Close()
+	last.Write([]byte("LIST"))
+	last.(*net.TCPConn).CloseWrite()
+	expectLine(t, protocol.NewConn(last), "ROOMS framed")
+}
+
+func expectLine(t *testing.T, conn *protocol.Conn, expected string) {
+	t.Helper()
+
+	line, err := conn.ReadLine()
+	if err != nil {
+		t.Fatalf("Error reading line: %v", err)
+	}
+	if line != expected {
+		t.Fatalf("Expected %q, got %q", expected, line)
+	}
+}
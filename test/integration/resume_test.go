@@ -0,0 +1,95 @@
+package server_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/protocol"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestResume(t *testing.T) {
+	config := server.DefaultConfig()
+	config.ResumeGrace = 200 * time.Millisecond
+	s := server.NewServerWithConfig(":8006", config)
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1, conn2, token := startGame(t, ":8006", "comeback")
+	defer conn1.Close()
+	defer conn2.Close()
+
+	expectResponse(t, conn1, "ATTACK 1 1", "HIT 1 1")
+	expectLine(t, conn2, "HIT 1 1")
+
+	// drop P1 mid-turn
+	conn1.Close()
+	expectLine(t, conn2, "DISCONNECTED P1")
+
+	conn3 := startConnection(t, ":8006")
+	defer conn3.Close()
+	expectResponse(t, conn3, "RESUME nope", "ERROR INVALID_SESSION invalid or expired session")
+	sendClientMessage(conn3, "RESUME "+token)
+
+	snapshot := readUntil(t, conn3, "END")
+	if snapshot[0] != "RESUMED P1 Alice size=10 rules=standard adjacency=allowed repeat=reject turns=fixed" {
+		t.Fatalf("Expected RESUMED header, got %q", snapshot[0])
+	}
+	expected := []string{"SHIP CARRIER 1 1 H", "SHIP SUBMARINE 0 9 H", "SHOT HIT 1 1", "STATE PLAYING", "TURN P1"}
+	for _, line := range expected {
+		if !slices.Contains(snapshot, line) {
+			t.Errorf("Expected %q in snapshot %q", line, snapshot)
+		}
+	}
+	expectLine(t, conn2, "RECONNECTED P1")
+
+	// the resumed player carries on with their turn
+	expectResponse(t, conn3, "ATTACK 2 1", "HIT 2 1")
+	expectLine(t, conn2, "HIT 2 1")
+
+	// after the grace period the seat is gone for good
+	conn3.Close()
+	expectLine(t, conn2, "DISCONNECTED P1")
+	expectLine(t, conn2, "WIN P2 FORFEIT")
+
+	conn4 := startConnection(t, ":8006")
+	defer conn4.Close()
+	expectResponse(t, conn4, "RESUME "+token, "ERROR INVALID_SESSION invalid or expired session")
+}
+
+// expectWelcome says HELLO and returns the session token from the WELCOME.
+func expectWelcome(t *testing.T, conn *protocol.Conn, name string, code string) string {
+	t.Helper()
+
+	sendClientMessage(conn, "HELLO "+name)
+	response, err := readResponse(conn)
+	if err != nil {
+		t.Fatalf("Error reading WELCOME: %v", err)
+	}
+	parts := strings.Fields(response)
+	if len(parts) != 9 || parts[0] != "WELCOME" || parts[1] != code || parts[2] != name ||
+		!strings.HasPrefix(parts[4], "size=") || !strings.HasPrefix(parts[5], "rules=") || !strings.HasPrefix(parts[6], "adjacency=") ||
+		!strings.HasPrefix(parts[7], "repeat=") || !strings.HasPrefix(parts[8], "turns=") {
+		t.Fatalf("Expected WELCOME %s %s <token> <rules>, got %q", code, name, response)
+	}
+	return parts[3]
+}
+
+// readUntil collects lines up to and including the terminator.
+func readUntil(t *testing.T, conn *protocol.Conn, terminator string) []string {
+	t.Helper()
+
+	var lines []string
+	for {
+		line, err := readResponse(conn)
+		if err != nil {
+			t.Fatalf("Error reading until %q: %v", terminator, err)
+		}
+		lines = append(lines, line)
+		if line == terminator {
+			return lines
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/protocol"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+// CLASSIC_FLEET is a legal fleet for the classic ruleset.
+var CLASSIC_FLEET = []string{
+	"SHIP CARRIER 0 0 H",
+	"SHIP BATTLESHIP 0 2 H",
+	"SHIP CRUISER 0 4 H",
+	"SHIP SUBMARINE 0 6 H",
+	"SHIP DESTROYER 0 8 H",
+}
+
+func TestRuleset(t *testing.T) {
+	s := server.NewServer(":8015")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1 := startConnection(t, ":8015")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8015")
+	defer conn2.Close()
+
+	expectMatch(t, sendLine(conn1, "CREATE chess rules=chess"), `^ERROR INVALID_RULESET `)
+	expectResponse(t, conn1, "CREATE hasbro rules=classic", "OK CREATE hasbro")
+	expectResponse(t, conn2, "JOIN hasbro", "OK JOIN hasbro")
+	expectMatch(t, sendLine(conn1, "HELLO Alice"), `^WELCOME P1 Alice \w+ size=10 rules=classic adjacency=allowed repeat=reject turns=fixed$`)
+	expectMatch(t, sendLine(conn2, "HELLO Bob"), `^WELCOME P2 Bob \w+ size=10 rules=classic adjacency=allowed repeat=reject turns=fixed$`)
+
+	// exact counts: one of each, with the classic shapes
+	expectResponse(t, conn1, "SHIP SUBMARINE 7 0 V", "OK SHIP SUBMARINE")
+	expectResponse(t, conn1, "SHIP SUBMARINE 9 0 V",
+		"ERROR TOO_MANY_SHIPS too many ships of this type: 1 of 1 SUBMARINE already placed")
+	expectResponse(t, conn1, "READY", "ERROR FLEET_INCOMPLETE fleet not complete: 0 of 1 CARRIER placed")
+	expectMatch(t, sendLine(conn1, "SHIP CARRIER 6 0 H"), `^ERROR OUT_OF_BOUNDS `)
+
+	placeFleet(t, conn1, CLASSIC_FLEET[:3]...)
+	placeFleet(t, conn1, CLASSIC_FLEET[4])
+	placeFleet(t, conn2, CLASSIC_FLEET...)
+	sendClientMessage(conn1, "READY")
+	sendClientMessage(conn2, "READY")
+	expectLine(t, conn1, "START P1")
+	expectLine(t, conn2, "START P1")
+	expectLine(t, conn1, "TURN P1")
+
+	// one shot per turn
+	expectResponse(t, conn1, "ATTACK 9 9", "MISS 9 9")
+	expectLine(t, conn2, "MISS 9 9")
+	expectLine(t, conn2, "TURN P2")
+}
+
+func placeFleet(t *testing.T, conn *protocol.Conn, ships ...string) {
+	t.Helper()
+
+	for _, ship := range ships {
+		expectMatch(t, sendLine(conn, ship), `^OK SHIP `)
+	}
+}
@@ -0,0 +1,164 @@
+package server_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/protocol"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestSalvo(t *testing.T) {
+	s := server.NewServer(":8020")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1 := startConnection(t, ":8020")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8020")
+	defer conn2.Close()
+
+	expectMatch(t, sendLine(conn1, "CREATE volley turns=often"), `^ERROR INVALID_TURNS `)
+	expectResponse(t, conn1, "CREATE volley turns=salvo", "OK CREATE volley")
+	expectResponse(t, conn2, "JOIN volley", "OK JOIN volley")
+	expectMatch(t, sendLine(conn1, "HELLO Alice"), `^WELCOME P1 Alice \w+ size=10 rules=standard adjacency=allowed repeat=reject turns=salvo$`)
+	expectMatch(t, sendLine(conn2, "HELLO Bob"), `^WELCOME P2 Bob \w+ size=10 rules=standard adjacency=allowed repeat=reject turns=salvo$`)
+	expectResponse(t, conn1, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+	expectResponse(t, conn2, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+	sendClientMessage(conn1, "READY")
+	sendClientMessage(conn2, "READY")
+	expectLine(t, conn1, "START P1")
+	expectLine(t, conn2, "START P1")
+	expectLine(t, conn1, "TURN P1 shots=11")
+
+	expectResponse(t, conn1, "ATTACK 0 0", "ERROR TURN_POLICY not allowed by the turn policy: salvo, fire with SALVO")
+	expectResponse(t, conn1, "SALVO 0 0 1 1", "ERROR SALVO_SIZE wrong number of shots in salvo: 2 shots, expected 11")
+	expectResponse(t, conn1, "SALVO 0 0 0 0 0 1 0 2 4 4 6 6 1 1 2 1 9 2 9 4 9 9",
+		"ERROR ALREADY_SHOT cell already attacked: (0, 0)")
+
+	// every shot lands before any ship is named
+	sendClientMessage(conn1, "SALVO 0 0 9 2 0 1 1 1 9 4 0 2 2 1 4 4 9 9 6 6 0 9")
+	expected := []string{
+		"SALVO P1 11",
+		"MISS 0 0", "HIT 9 2", "MISS 0 1", "HIT 1 1", "HIT 9 4", "MISS 0 2",
+		"HIT 2 1", "MISS 4 4", "HIT 9 9", "MISS 6 6", "HIT 0 9",
+		"SUNK 9 2 SUBMARINE", "SUNK 9 4 SUBMARINE", "SUNK 9 9 SUBMARINE", "SUNK 0 9 SUBMARINE",
+		"END",
+	}
+	for _, conn := range []*protocol.Conn{conn1, conn2} {
+		if salvo := readUntil(t, conn, "END"); strings.Join(salvo, "\n") != strings.Join(expected, "\n") {
+			t.Fatalf("Expected salvo %q, got %q", expected, salvo)
+		}
+	}
+	expectLine(t, conn2, "TURN P2 shots=7")
+
+	// a salvo is checked as a whole, so a bad one fires nothing
+	expectResponse(t, conn2, "SALVO 9 2 9 4 1 1 0 0 0 1 0 2 10 10", "ERROR OUT_OF_BOUNDS coordinate out of bounds: (10, 10)")
+	sendClientMessage(conn2, "SALVO 9 2 9 4 1 1 0 0 0 1 0 2 4 4")
+	readUntil(t, conn1, "END")
+	readUntil(t, conn2, "END")
+	expectLine(t, conn1, "TURN P1 shots=9")
+
+	t.Run("vs AI", func(t *testing.T) {
+		conn := startConnection(t, ":8020")
+		defer conn.Close()
+
+		expectMatch(t, sendLine(conn, "HELLO Human VS_AI DENSITY turns=salvo repeat=miss"), `^WELCOME P1 Human \w+ size=10 rules=standard adjacency=allowed repeat=miss turns=salvo$`)
+		expectResponse(t, conn, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+		expectResponse(t, conn, "READY", "START P1")
+		playSalvosAgainstAI(t, conn)
+	})
+}
+
+// playSalvosAgainstAI sweeps the board cell by cell, a salvo at a time, until
+// either side wins. Once the board is swept shots land on (0, 0) again, which
+// repeat=miss allows.
+func playSalvosAgainstAI(t *testing.T, conn *protocol.Conn) {
+	t.Helper()
+
+	next := 0
+	for {
+		line, err := readResponse(conn)
+		if err != nil {
+			t.Fatalf("Connection closed before the game ended: %v", err)
+		}
+		if strings.HasPrefix(line, "WIN ") {
+			expectClosed(t, conn)
+			return
+		}
+		shots, found := strings.CutPrefix(line, "TURN P1 shots=")
+		if !found {
+			continue
+		}
+
+		var n int
+		fmt.Sscan(shots, &n)
+		salvo := "SALVO"
+		for range n {
+			cell := next % (game.DEFAULT_BOARD_SIZE * game.DEFAULT_BOARD_SIZE)
+			next++
+			salvo += fmt.Sprintf(" %d %d", cell/game.DEFAULT_BOARD_SIZE, cell%game.DEFAULT_BOARD_SIZE)
+		}
+		expectResponse(t, conn, salvo, fmt.Sprintf("SALVO P1 %d", n))
+	}
+}
+
+// TestSalvoFewCellsLeft checks that a salvo never has to be larger than the
+// cells the opponent has left to shoot at, or the game could not go on.
+func TestSalvoFewCellsLeft(t *testing.T) {
+	g := game.NewGame()
+	g.Rules = g.Rules.WithTurns(game.TURNS_SALVO)
+	apply(t, g, game.Join{Seat: 0, Id: 1, Name: "alice"})
+	apply(t, g, game.Join{Seat: 1, Id: 2, Name: "bob"})
+	for seat := range 2 {
+		apply(t, g, game.PlaceFleet{Seat: seat, Placements: standardPlacements()})
+		apply(t, g, game.Ready{Seat: seat})
+	}
+
+	// P1 sinks ships first, so P2 fires fewer shots, and leaves the
+	// submarine at (0, 9) for last. P2 only shoots at water.
+	last := game.Vector2{X: 0, Y: 9}
+	ships := make(map[game.Vector2]bool)
+	for _, placement := range standardPlacements() {
+		cells, _ := g.Rules.ShipCells(placement.ShipType, placement.X, placement.Y, placement.Direction, g.BoardSize)
+		for _, cell := range cells {
+			ships[cell] = true
+		}
+	}
+	var targets [2][]game.Vector2
+	for y := range g.BoardSize {
+		for x := range g.BoardSize {
+			cell := game.Vector2{X: x, Y: y}
+			switch {
+			case cell == last:
+			case ships[cell]:
+				targets[0] = append([]game.Vector2{cell}, targets[0]...)
+			default:
+				targets[0] = append(targets[0], cell)
+				targets[1] = append(targets[1], cell)
+			}
+		}
+	}
+
+	for len(targets[0]) > 0 {
+		seat := g.CurrentSeat()
+		n := g.ShotsPerTurn(g.PlayerAt(seat))
+		apply(t, g, game.Salvo{Seat: seat, Targets: targets[seat][:n]})
+		targets[seat] = targets[seat][n:]
+	}
+	if seat := g.CurrentSeat(); seat != 1 {
+		t.Fatalf("Expected P2 to play, got seat %d", seat)
+	}
+	apply(t, g, game.Salvo{Seat: 1, Targets: targets[1][:g.ShotsPerTurn(g.PlayerAt(1))]})
+
+	if n := g.ShotsPerTurn(g.PlayerAt(0)); n != 1 {
+		t.Fatalf("Expected a salvo of 1 with 1 cell left, got %d", n)
+	}
+	events := apply(t, g, game.Salvo{Seat: 0, Targets: []game.Vector2{last}})
+	if won := events[len(events)-1]; won != (game.GameWon{Seat: 0}) {
+		t.Fatalf("Expected P1 to win, got %v", won)
+	}
+}
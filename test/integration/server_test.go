@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/protocol"
 	"github.com/pmouraguedes/battleship/internal/server"
 )
 
@@ -53,12 +54,6 @@ var POSITIONS = [25]game.Vector2{
 	{X: 0, Y: 9},
 }
 
-var (
-	turnPlayerCode = "P1"
-	errChan        = make(chan error, 2)
-	wg             sync.WaitGroup
-)
-
 func TestServer(t *testing.T) {
 	// Create a new server instance
 	s := server.NewServer(":8000")
@@ -69,28 +64,76 @@ func TestServer(t *testing.T) {
 	// Allow some time for the server to start
 	time.Sleep(1 * time.Millisecond)
 
+	if err := playMatch(":8000", "", "Player1", "Player2"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConcurrentGames(t *testing.T) {
+	const matches = 4
+
+	s := server.NewServer(":8001")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := range matches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := playMatch(":8001", fmt.Sprintf("room%d", i), fmt.Sprintf("Alice%d", i), fmt.Sprintf("Bob%d", i)); err != nil {
+				t.Errorf("Match %d: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// playMatch plays a full game between two new connections and returns the
+// first client error. It does not touch t, as it runs on goroutines of its
+// own. With an empty room the players are paired by the matchmaking queue,
+// otherwise the first one creates the room and the second one joins it.
+func playMatch(address string, room string, name1 string, name2 string) error {
 	// Create a connection to the server
-	conn1 := startConnection(t, ":8000")
+	conn1, err := dial(address)
+	if err != nil {
+		return err
+	}
 	defer conn1.Close()
 	log.Printf("[test] conn1 created")
 
 	// Create a second connection to the server
-	conn2 := startConnection(t, ":8000")
+	conn2, err := dial(address)
+	if err != nil {
+		return err
+	}
 	defer conn2.Close()
 	log.Printf("[test] conn2 created")
 
+	if room != "" {
+		if err := exchange(conn1, "CREATE "+room, "OK CREATE "+room); err != nil {
+			return err
+		}
+		if err := exchange(conn2, "JOIN "+room, "OK JOIN "+room); err != nil {
+			return err
+		}
+	}
+
+	errChan := make(chan error, 2)
+
 	// Create a channel to synchronize the two clients
+	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		doClientStuff(conn1, "P1", "Player1")
+		errChan <- doClientStuff(conn1, "P1", name1)
 	}()
 	time.Sleep(100 * time.Millisecond)
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		doClientStuff(conn2, "P2", "Player2")
+		errChan <- doClientStuff(conn2, "P2", name2)
 	}()
 
 	wg.Wait()
@@ -99,25 +142,38 @@ func TestServer(t *testing.T) {
 
 	for err := range errChan {
 		if err != nil {
-			t.Fatalf("Error in client: %v", err)
+			return fmt.Errorf("error in client: %w", err)
 		}
 	}
+	return nil
 }
 
-func startConnection(t *testing.T, address string) net.Conn {
-	conn, err := net.Dial("tcp", address)
+func startConnection(t *testing.T, address string) *protocol.Conn {
+	conn, err := dial(address)
 	if err != nil {
-		t.Fatalf("Failed to connect to server: %v", err)
+		t.Fatal(err)
 	}
 	return conn
 }
 
-func doClientStuff(conn net.Conn, clientCode string, clientName string) {
+func dial(address string) (*protocol.Conn, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to server: %w", err)
+	}
+	return protocol.NewConn(conn), nil
+}
+
+func doClientStuff(conn *protocol.Conn, clientCode string, clientName string) error {
 	// HELLO message
-	sendHelloMessage(conn, clientCode, clientName)
+	if err := sendHelloMessage(conn, clientCode, clientName); err != nil {
+		return err
+	}
 
 	// SHIP messages
-	sendFleetMessages(conn, clientCode)
+	if err := sendFleetMessages(conn, clientCode); err != nil {
+		return err
+	}
 
 	attacks := 0
 
@@ -127,35 +183,36 @@ func doClientStuff(conn net.Conn, clientCode string, clientName string) {
 			log.Printf("[client %s] connection closed by server", clientCode)
 			break
 		} else if err != nil {
-			errChan <- fmt.Errorf("Error reading response: %v", err)
-			return
+			return fmt.Errorf("Error reading response: %v", err)
 		}
 
 		log.Printf("[client %s] received: %s", clientCode, msg)
-		if msg == fmt.Sprintf("TURN %s\n", clientCode) {
+		if msg == fmt.Sprintf("TURN %s", clientCode) {
 			log.Printf("[client %s] received TURN message", clientCode)
 
 			// ATTACK messages
-			sendAttackMessages(conn, clientCode, attacks)
+			if err := sendAttackMessages(conn, clientCode, attacks); err != nil {
+				return err
+			}
 			attacks += 3
 		} else if gameOver := strings.HasPrefix(msg, "WIN"); gameOver {
 			log.Printf("[client %s] received WIN message", clientCode)
 		}
 	}
+	return nil
 }
 
-func sendAttackMessages(conn net.Conn, clientCode string, attacks int) {
+func sendAttackMessages(conn *protocol.Conn, clientCode string, attacks int) error {
 	for i := 0; i != game.TURN_MAX_ATTACKS; i++ {
 		log.Printf("[client %s] sending attack message #%d", clientCode, attacks+i)
 
 		position := POSITIONS[attacks+i]
 
-		attackMessage := fmt.Sprintf("ATTACK %d %d\n", position.X, position.Y)
+		attackMessage := fmt.Sprintf("ATTACK %d %d", position.X, position.Y)
 		sendClientMessage(conn, attackMessage)
 		resp, err := readResponse(conn)
 		if err != nil {
-			errChan <- fmt.Errorf("Error reading response: %v", err)
-			return
+			return fmt.Errorf("Error reading response: %v", err)
 		}
 		log.Printf("[client %s] received: %s", clientCode, resp)
 
@@ -167,54 +224,49 @@ func sendAttackMessages(conn net.Conn, clientCode string, attacks int) {
 			!strings.HasPrefix(resp, "MISS") &&
 			!strings.HasPrefix(resp, "SUNK") {
 			log.Printf("[client %s] received unexpected: %s", clientCode, resp)
-			errChan <- fmt.Errorf("Expected HIT or MISS message, got: %s", resp)
-			return
+			return fmt.Errorf("Expected HIT or MISS message, got: %s", resp)
 		}
 	}
+	return nil
 }
 
-func sendHelloMessage(conn net.Conn, clientCode string, clientName string) {
-	helloMessage := "HELLO " + clientName + "\n"
+func sendHelloMessage(conn *protocol.Conn, clientCode string, clientName string) error {
+	helloMessage := "HELLO " + clientName
 	sendClientMessage(conn, helloMessage)
 
 	response, err := readResponse(conn)
 	if err != nil {
-		errChan <- fmt.Errorf("Error reading response: %v", err)
-		return
+		return fmt.Errorf("Error reading response: %v", err)
 	}
-	if response != "WELCOME "+clientCode+" "+clientName+"\n" {
-		errChan <- fmt.Errorf("Expected welcome message, got: %s", response)
-		return
+	if !strings.HasPrefix(response, "WELCOME "+clientCode+" "+clientName+" ") {
+		return fmt.Errorf("Expected welcome message, got: %s", response)
 	}
 
 	log.Printf("[client] %s received: %s", clientCode, response)
+	return nil
 }
 
-func sendFleetMessages(conn net.Conn, clientCode string) {
+func sendFleetMessages(conn *protocol.Conn, clientCode string) error {
 	// carrier
-	carrierMessage := "SHIP CARRIER 1 1 H\n"
+	carrierMessage := "SHIP CARRIER 1 1 H"
 	sendClientMessage(conn, carrierMessage)
 	response, err := readResponse(conn)
 	if err != nil {
-		errChan <- fmt.Errorf("Error reading response: %v", err)
-		return
+		return fmt.Errorf("Error reading response: %v", err)
 	}
-	if response != "OK SHIP CARRIER\n" {
+	if response != "OK SHIP CARRIER" {
 		// t.Fatalf("Expected OK message, got: %s", response)
-		errChan <- fmt.Errorf("Expected OK message, got: %s", response)
-		return
+		return fmt.Errorf("Expected OK message, got: %s", response)
 	}
 	// cruiser
 	cruiserMessage := "SHIP CRUISER 5 0 V"
 	sendClientMessage(conn, cruiserMessage)
 	response, err = readResponse(conn)
 	if err != nil {
-		errChan <- fmt.Errorf("Error reading response: %v", err)
-		return
+		return fmt.Errorf("Error reading response: %v", err)
 	}
-	if response != "OK SHIP CRUISER\n" {
-		errChan <- fmt.Errorf("Expected OK message, got: %s", response)
-		return
+	if response != "OK SHIP CRUISER" {
+		return fmt.Errorf("Expected OK message, got: %s", response)
 	}
 	// 2 battleships
 	battleshipMessages := []string{
@@ -225,12 +277,10 @@ func sendFleetMessages(conn net.Conn, clientCode string) {
 		sendClientMessage(conn, msg)
 		response, err := readResponse(conn)
 		if err != nil {
-			errChan <- fmt.Errorf("Error reading response: %v", err)
-			return
+			return fmt.Errorf("Error reading response: %v", err)
 		}
-		if response != "OK SHIP BATTLESHIP\n" {
-			errChan <- fmt.Errorf("Expected OK message, got: %s", response)
-			return
+		if response != "OK SHIP BATTLESHIP" {
+			return fmt.Errorf("Expected OK message, got: %s", response)
 		}
 	}
 	// 3 destroyers
@@ -243,12 +293,10 @@ func sendFleetMessages(conn net.Conn, clientCode string) {
 		sendClientMessage(conn, msg)
 		response, err := readResponse(conn)
 		if err != nil {
-			errChan <- fmt.Errorf("Error reading response: %v", err)
-			return
+			return fmt.Errorf("Error reading response: %v", err)
 		}
-		if response != "OK SHIP DESTROYER\n" {
-			errChan <- fmt.Errorf("Expected OK message, got: %s", response)
-			return
+		if response != "OK SHIP DESTROYER" {
+			return fmt.Errorf("Expected OK message, got: %s", response)
 		}
 	}
 	// 4 submarines
@@ -262,35 +310,31 @@ func sendFleetMessages(conn net.Conn, clientCode string) {
 		sendClientMessage(conn, msg)
 		response, err := readResponse(conn)
 		if err != nil {
-			errChan <- fmt.Errorf("Error reading response: %v", err)
-			return
+			return fmt.Errorf("Error reading response: %v", err)
 		}
 
-		if response != "OK SHIP SUBMARINE\n" {
-			errChan <- fmt.Errorf("Expected OK message, got: %s", response)
-			return
+		if response != "OK SHIP SUBMARINE" {
+			return fmt.Errorf("Expected OK message, got: %s", response)
 		}
 	}
 
 	log.Printf("[client %s] finished sending fleet messages", clientCode)
-	sendClientMessage(conn, "READY\n")
+	sendClientMessage(conn, "READY")
 
 	response, err = readResponse(conn)
 	if err != nil {
-		errChan <- fmt.Errorf("Error reading response: %v", err)
-		return
+		return fmt.Errorf("Error reading response: %v", err)
 	}
 
-	if response != "START P1\n" {
-		errChan <- fmt.Errorf("Expected START message, got: %s", response)
-		return
+	if response != "START P1" {
+		return fmt.Errorf("Expected START message, got: %s", response)
 	}
 	log.Printf("[client %s] received START P1 message", clientCode)
+	return nil
 }
 
-func readResponse(conn net.Conn) (string, error) {
-	buf := make([]byte, 1024)
-	n, err := conn.Read(buf)
+func readResponse(conn *protocol.Conn) (string, error) {
+	msg, err := conn.ReadLine()
 	if err != nil {
 		if err == io.EOF {
 			log.Println("[test] connection closed by server")
@@ -300,12 +344,12 @@ func readResponse(conn net.Conn) (string, error) {
 		return "", err
 	}
 
-	return string(buf[:n]), nil
+	return msg, nil
 }
 
-func sendClientMessage(conn net.Conn, message string) error {
+func sendClientMessage(conn *protocol.Conn, message string) error {
 	log.Printf("[client] sendClientMessage - sending: %s", message)
-	_, err := conn.Write([]byte(message))
+	err := conn.WriteLine(message)
 	if err != nil {
 		log.Println("Error sending client message:", err)
 		return err
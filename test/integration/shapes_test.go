@@ -0,0 +1,62 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+const TETRIS_RULESETS = `{
+  "rulesets": [
+    {
+      "name": "tetris",
+      "attacksPerTurn": 1,
+      "ships": [
+        { "type": "L", "count": 1, "shape": [[0, 0], [0, 1], [0, 2], [1, 2]], "mirror": true },
+        { "type": "I", "count": 1, "shape": [[0, 0], [1, 0]] }
+      ]
+    }
+  ]
+}`
+
+func TestShapes(t *testing.T) {
+	broken := strings.Replace(TETRIS_RULESETS, "[1, 2]", "[2, 2]", 1)
+	if _, err := game.ReadRulesets(strings.NewReader(broken)); err == nil {
+		t.Fatalf("Expected a disconnected shape to be rejected")
+	}
+	tetris, err := game.ReadRulesets(strings.NewReader(TETRIS_RULESETS))
+	if err != nil {
+		t.Fatalf("Error reading rulesets: %v", err)
+	}
+
+	config := server.DefaultConfig()
+	config.Rulesets = game.Rulesets.With(tetris...)
+	s := server.NewServerWithConfig(":8016", config)
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1 := startConnection(t, ":8016")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8016")
+	defer conn2.Close()
+
+	expectResponse(t, conn1, "CREATE blocks rules=tetris", "OK CREATE blocks")
+	expectResponse(t, conn2, "JOIN blocks", "OK JOIN blocks")
+	expectWelcome(t, conn1, "Alice", "P1")
+	expectWelcome(t, conn2, "Bob", "P2")
+
+	expectResponse(t, conn1, "SHIP I 0 0 45", "ERROR INVALID_DIRECTION invalid direction: 45")
+	expectResponse(t, conn1, "SHIP I 0 0 0M", "ERROR INVALID_DIRECTION invalid direction: I cannot be mirrored")
+
+	// rotated half a turn the L covers (5,5) (5,4) (5,3) (4,3)
+	expectResponse(t, conn1, "SHIP L 5 5 180", "OK SHIP L")
+	expectResponse(t, conn1, "SHIP I 4 3 H", "ERROR OVERLAP position already occupied: (4, 3)")
+
+	// mirrored and turned a quarter it covers (0,0) (1,0) (2,0) (2,1)
+	expectResponse(t, conn2, "SHIP L 0 0 90M", "OK SHIP L")
+	expectResponse(t, conn2, "SHIP I 2 1 V", "ERROR OVERLAP position already occupied: (2, 1)")
+	expectResponse(t, conn2, "SHIP I 3 1 V", "OK SHIP I")
+}
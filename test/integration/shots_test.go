@@ -0,0 +1,96 @@
+package server_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/game"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestRepeatShots(t *testing.T) {
+	s := server.NewServer(":8019")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1, conn2, _ := startGame(t, ":8019", "twice")
+	defer conn1.Close()
+	defer conn2.Close()
+
+	// by default a repeat shot is refused and can be fired elsewhere
+	expectResponse(t, conn1, "ATTACK 9 2", "SUNK 9 2 SUBMARINE")
+	expectResponse(t, conn1, "ATTACK 9 2", "ERROR ALREADY_SHOT cell already attacked: (9, 2)")
+	expectResponse(t, conn1, "ATTACK 0 0", "MISS 0 0")
+	expectResponse(t, conn1, "ATTACK 1 1", "HIT 1 1")
+	readUntil(t, conn2, "TURN P2")
+
+	board := readUntil(t, sendLine(conn1, "BOARD"), "END")
+	expected := []string{
+		"BOARD 10",
+		"o.........",
+		".x........",
+		".........#",
+	}
+	for range 7 {
+		expected = append(expected, "..........")
+	}
+	expected = append(expected, "END")
+	if strings.Join(board, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected board %q, got %q", expected, board)
+	}
+
+	// with repeat=miss the shot is wasted, and the cell is not hit again
+	conn3 := startConnection(t, ":8019")
+	defer conn3.Close()
+	conn4 := startConnection(t, ":8019")
+	defer conn4.Close()
+
+	expectMatch(t, sendLine(conn3, "CREATE wasted repeat=sometimes"), `^ERROR INVALID_REPEAT `)
+	expectResponse(t, conn3, "CREATE wasted repeat=miss turns=fixed", "OK CREATE wasted")
+	expectResponse(t, conn4, "JOIN wasted", "OK JOIN wasted")
+	expectMatch(t, sendLine(conn3, "HELLO Carol"), `^WELCOME P1 Carol \w+ size=10 rules=standard adjacency=allowed repeat=miss turns=fixed$`)
+	expectMatch(t, sendLine(conn4, "HELLO Dave"), `^WELCOME P2 Dave \w+ size=10 rules=standard adjacency=allowed repeat=miss turns=fixed$`)
+	expectResponse(t, conn3, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+	expectResponse(t, conn4, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+	sendClientMessage(conn3, "READY")
+	sendClientMessage(conn4, "READY")
+	readUntil(t, conn3, "TURN P1")
+	readUntil(t, conn4, "START P1")
+
+	expectResponse(t, conn3, "ATTACK 1 1", "HIT 1 1")
+	expectResponse(t, conn3, "ATTACK 1 1", "MISS 1 1")
+	expectResponse(t, conn3, "ATTACK 1 1", "MISS 1 1")
+	readUntil(t, conn4, "TURN P2")
+
+	board = readUntil(t, sendLine(conn3, "BOARD"), "END")
+	if board[2] != ".x........" {
+		t.Fatalf("Expected a single hit at (1, 1), got %q", board[2])
+	}
+}
+
+// TestRepeatMissRecorded checks that a wasted repeat shot stays in the shot
+// history, without hiding the hit it repeated.
+func TestRepeatMissRecorded(t *testing.T) {
+	g := game.NewGame()
+	g.Rules = g.Rules.WithRepeat(game.REPEAT_MISS)
+	apply(t, g, game.Join{Seat: 0, Id: 1, Name: "alice"})
+	apply(t, g, game.Join{Seat: 1, Id: 2, Name: "bob"})
+	for seat := range 2 {
+		apply(t, g, game.PlaceFleet{Seat: seat, Placements: standardPlacements()})
+		apply(t, g, game.Ready{Seat: seat})
+	}
+
+	target := game.Vector2{X: 1, Y: 1}
+	apply(t, g, game.Attack{Seat: 0, Target: target})
+	apply(t, g, game.Attack{Seat: 0, Target: target})
+
+	expected := []game.Shot{{Position: target, Hit: true}, {Position: target}}
+	if shots := g.PlayerAt(1).Fleet.Shots(); !reflect.DeepEqual(shots, expected) {
+		t.Fatalf("Expected %v, got %v", expected, shots)
+	}
+	if cell := g.PlayerAt(1).Fleet.ShotGrid()[1][1]; cell != game.CELL_HIT {
+		t.Fatalf("Expected the hit to stay on the grid, got %v", cell)
+	}
+}
@@ -0,0 +1,91 @@
+package server_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestSpectator(t *testing.T) {
+	s := server.NewServer(":8012")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1, conn2, _ := startGame(t, ":8012", "arena")
+	defer conn1.Close()
+	defer conn2.Close()
+	expectResponse(t, conn1, "ATTACK 0 0", "MISS 0 0")
+	expectLine(t, conn2, "MISS 0 0")
+
+	spectator := startConnection(t, ":8012")
+	defer spectator.Close()
+	expectResponse(t, spectator, "WATCH 99", "ERROR GAME_NOT_FOUND game not found: 99")
+	expectResponse(t, spectator, "GAMES", "GAMES 1")
+
+	sendClientMessage(spectator, "WATCH 1")
+	snapshot := readUntil(t, spectator, "END")
+	expected := []string{"WATCHING 1 size=10 rules=standard adjacency=allowed repeat=reject turns=fixed", "PLAYER P1 Alice", "PLAYER P2 Bob", "SHOT P1 MISS 0 0", "TURN P1", "END"}
+	if !slices.Equal(snapshot, expected) {
+		t.Fatalf("Expected snapshot %q, got %q", expected, snapshot)
+	}
+
+	// the spectator follows the game but cannot play
+	expectResponse(t, conn1, "ATTACK 1 1", "HIT 1 1")
+	expectLine(t, spectator, "HIT 1 1")
+	expectResponse(t, conn1, "ATTACK 0 1", "MISS 0 1")
+	expectLine(t, spectator, "MISS 0 1")
+	expectLine(t, spectator, "TURN P2")
+	expectMatch(t, sendLine(spectator, "ATTACK 2 2"), `^ERROR UNEXPECTED_COMMAND `)
+	readUntil(t, conn2, "TURN P2")
+
+	// when the game is over the spectator is back in the lobby
+	expectResponse(t, conn2, "QUIT", "BYE")
+	expectLine(t, spectator, "WIN P1 FORFEIT")
+	expectResponse(t, spectator, "GAMES", "GAMES")
+}
+
+func TestOmniscientSpectator(t *testing.T) {
+	config := server.DefaultConfig()
+	config.Omniscient = true
+	s := server.NewServerWithConfig(":8013", config)
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	conn1 := startConnection(t, ":8013")
+	defer conn1.Close()
+	conn2 := startConnection(t, ":8013")
+	defer conn2.Close()
+	spectator := startConnection(t, ":8013")
+	defer spectator.Close()
+
+	expectResponse(t, conn1, "CREATE open", "OK CREATE open")
+	sendClientMessage(spectator, "WATCH 1")
+	readUntil(t, spectator, "END")
+	expectResponse(t, conn2, "JOIN open", "OK JOIN open")
+	expectWelcome(t, conn1, "Alice", "P1")
+	expectWelcome(t, conn2, "Bob", "P2")
+
+	errChan := make(chan error, 2)
+	go func() { errChan <- sendFleetMessages(conn1, "P1") }()
+	go func() { errChan <- sendFleetMessages(conn2, "P2") }()
+	for range 2 {
+		if err := <-errChan; err != nil {
+			t.Fatalf("Error placing fleet: %v", err)
+		}
+	}
+
+	// both fleets are revealed when the game starts
+	lines := readUntil(t, spectator, "TURN P1")
+	ships := 0
+	for _, line := range lines {
+		if strings.HasPrefix(line, "SHIP P1 ") || strings.HasPrefix(line, "SHIP P2 ") {
+			ships++
+		}
+	}
+	if lines[0] != "START P1" || ships != 22 {
+		t.Fatalf("Expected START P1 and 22 ships, got %q", lines)
+	}
+}
@@ -0,0 +1,65 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pmouraguedes/battleship/internal/protocol"
+	"github.com/pmouraguedes/battleship/internal/server"
+)
+
+func TestTurnPolicies(t *testing.T) {
+	s := server.NewServer(":8021")
+	go s.Start()
+	time.Sleep(1 * time.Millisecond)
+
+	t.Run("single", func(t *testing.T) {
+		conn1, conn2 := startTurnsGame(t, "once", "single")
+		defer conn1.Close()
+		defer conn2.Close()
+
+		expectResponse(t, conn1, "ATTACK 1 1", "HIT 1 1")
+		readUntil(t, conn2, "TURN P2")
+		expectResponse(t, conn1, "ATTACK 2 1", "ERROR UNEXPECTED_COMMAND unexpected command: ATTACK")
+		expectResponse(t, conn2, "ATTACK 0 0", "MISS 0 0")
+		readUntil(t, conn1, "TURN P1")
+	})
+
+	t.Run("until-miss", func(t *testing.T) {
+		conn1, conn2 := startTurnsGame(t, "streak", "until-miss")
+		defer conn1.Close()
+		defer conn2.Close()
+
+		expectResponse(t, conn1, "ATTACK 1 1", "HIT 1 1")
+		expectResponse(t, conn1, "ATTACK 2 1", "HIT 2 1")
+		expectResponse(t, conn1, "ATTACK 9 2", "SUNK 9 2 SUBMARINE")
+		expectResponse(t, conn1, "ATTACK 1 1", "ERROR ALREADY_SHOT cell already attacked: (1, 1)")
+		expectResponse(t, conn1, "ATTACK 9 4", "SUNK 9 4 SUBMARINE")
+		expectResponse(t, conn1, "ATTACK 0 0", "MISS 0 0")
+		readUntil(t, conn2, "TURN P2")
+		expectResponse(t, conn2, "ATTACK 0 0", "MISS 0 0")
+		readUntil(t, conn1, "TURN P1")
+	})
+}
+
+// startTurnsGame starts a game in room played with the turns policy and
+// waits for P1's first TURN.
+func startTurnsGame(t *testing.T, room string, turns string) (*protocol.Conn, *protocol.Conn) {
+	t.Helper()
+
+	conn1 := startConnection(t, ":8021")
+	conn2 := startConnection(t, ":8021")
+
+	expectResponse(t, conn1, "CREATE "+room+" turns="+turns, "OK CREATE "+room)
+	expectResponse(t, conn2, "JOIN "+room, "OK JOIN "+room)
+	expectMatch(t, sendLine(conn1, "HELLO Alice"), ` turns=`+turns+`$`)
+	expectMatch(t, sendLine(conn2, "HELLO Bob"), ` turns=`+turns+`$`)
+	expectResponse(t, conn1, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+	expectResponse(t, conn2, "FLEET "+strings.Join(STANDARD_FLEET, " "), "OK FLEET 11")
+	sendClientMessage(conn1, "READY")
+	sendClientMessage(conn2, "READY")
+	readUntil(t, conn1, "TURN P1")
+	readUntil(t, conn2, "START P1")
+	return conn1, conn2
+}